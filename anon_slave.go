@@ -82,7 +82,7 @@ func (s *AnonSlave) Subscribe(lsns ...uint64) (it PacketIterator, err error) {
 	s.next = s.nextXlog
 
 	// Start sending heartbeat messages to master
-	go s.heartbeat()
+	s.startHeartbeat()
 
 	return s, nil
 }
@@ -123,6 +123,12 @@ func (s *AnonSlave) Attach(out ...chan *Packet) (it PacketIterator, err error) {
 	return nil, nil
 }
 
+// Resume subscribes for DML requests since the position saved to the store set by SetPositionStore
+// or fetches the snapshot first if there is no position or the master has no xlogs since it, see Slave.Resume.
+func (s *AnonSlave) Resume(out ...chan *Packet) (it PacketIterator, err error) {
+	return s.resume(s.JoinWithSnap, s.Subscribe, out...)
+}
+
 func (s *AnonSlave) fetchSnapshot() (err error) {
 	pp, err := s.newPacket(&FetchSnapshot{})
 	if err != nil {
@@ -207,6 +213,7 @@ func (s *AnonSlave) subscribe(lsns ...uint64) error {
 		s.ReplicaSet.UUID = sub.ReplicaSetUUID
 	}
 	s.VClock = sub.VClock
+	s.followMaster(sub.VClock, vc)
 
 	return nil
 }
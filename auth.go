@@ -2,23 +2,112 @@ package tarantool
 
 import (
 	"crypto/sha1"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"sync"
 
 	"github.com/tinylib/msgp/msgp"
 )
 
+// Auth is the IPROTO_AUTH request. Method is AuthChapSha1 if it is empty.
+// On the server side GreetingAuth is the authentication data received from the client,
+// see IprotoServer.VerifyAuth.
 type Auth struct {
 	User         string
 	Password     string
 	GreetingAuth []byte
+	Method       string
 }
 
 var _ Query = (*Auth)(nil)
 
-const authHash = "chap-sha1"
+// Authentication methods
+const (
+	AuthChapSha1  = "chap-sha1"
+	AuthPapSha256 = "pap-sha256" // Tarantool EE, TLS only
+)
+
 const scrambleSize = sha1.Size // == 20
 
+// AuthMethod produces the authentication data of IPROTO_AUTH and verifies it on the server side.
+type AuthMethod interface {
+	// Name is the method name sent to the server, e.g. "chap-sha1".
+	Name() string
+	// AppendAuthData appends the authentication data of the password to b,
+	// salt is the base64-encoded salt of the greeting.
+	AppendAuthData(b []byte, salt []byte, password string) ([]byte, error)
+	// Verify checks the authentication data received from the client.
+	Verify(salt []byte, data []byte, password string) bool
+}
+
+var authMethods = struct {
+	sync.RWMutex
+	m map[string]AuthMethod
+}{m: make(map[string]AuthMethod)}
+
+func init() {
+	RegisterAuthMethod(chapSha1{})
+	RegisterAuthMethod(papSha256{})
+}
+
+// RegisterAuthMethod makes the method available by its name for Options.AuthMethod,
+// Auth and IprotoServer. The registered method with the same name is replaced.
+func RegisterAuthMethod(method AuthMethod) {
+	authMethods.Lock()
+	authMethods.m[method.Name()] = method
+	authMethods.Unlock()
+}
+
+func getAuthMethod(name string) (AuthMethod, bool) {
+	if name == "" {
+		name = AuthChapSha1
+	}
+	authMethods.RLock()
+	method, ok := authMethods.m[name]
+	authMethods.RUnlock()
+	return method, ok
+}
+
+// chapSha1 sends the scramble of the password hash, see scramble.
+type chapSha1 struct{}
+
+func (chapSha1) Name() string {
+	return AuthChapSha1
+}
+
+func (chapSha1) AppendAuthData(b []byte, salt []byte, password string) ([]byte, error) {
+	scr, err := scramble(salt, password)
+	if err != nil {
+		return b, fmt.Errorf("auth: scrambling failure: %s", err.Error())
+	}
+	return msgp.AppendBytes(b, scr), nil
+}
+
+func (chapSha1) Verify(salt []byte, data []byte, password string) bool {
+	scr, err := scramble(salt, password)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(scr, data) == 1
+}
+
+// papSha256 sends the password as is, so it must be used with TLS only.
+// Tarantool stores sha256 of the password with a per-user salt.
+type papSha256 struct{}
+
+func (papSha256) Name() string {
+	return AuthPapSha256
+}
+
+func (papSha256) AppendAuthData(b []byte, salt []byte, password string) ([]byte, error) {
+	return msgp.AppendString(b, password), nil
+}
+
+func (papSha256) Verify(salt []byte, data []byte, password string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), data) == 1
+}
+
 // copy-paste from go-tarantool
 func scramble(encodedSalt []byte, pass string) (scramble []byte, err error) {
 	/* ==================================================================
@@ -61,9 +150,9 @@ func (auth *Auth) GetCommandID() uint {
 
 // MarshalMsg implements msgp.Marshaler
 func (auth *Auth) MarshalMsg(b []byte) (o []byte, err error) {
-	scr, err := scramble(auth.GreetingAuth, auth.Password)
-	if err != nil {
-		return nil, fmt.Errorf("auth: scrambling failure: %s", err.Error())
+	method, ok := getAuthMethod(auth.Method)
+	if !ok {
+		return nil, fmt.Errorf("auth: unknown method %#v", auth.Method)
 	}
 
 	o = b
@@ -73,8 +162,10 @@ func (auth *Auth) MarshalMsg(b []byte) (o []byte, err error) {
 
 	o = msgp.AppendUint(o, KeyTuple)
 	o = msgp.AppendArrayHeader(o, 2)
-	o = msgp.AppendString(o, authHash)
-	o = msgp.AppendBytes(o, scr)
+	o = msgp.AppendString(o, method.Name())
+	if o, err = method.AppendAuthData(o, auth.GreetingAuth, auth.Password); err != nil {
+		return nil, err
+	}
 
 	return o, nil
 }
@@ -106,7 +197,7 @@ func (auth *Auth) UnmarshalMsg(data []byte) (buf []byte, err error) {
 			if l == 2 {
 				var obuf []byte
 
-				if buf, err = msgp.Skip(buf); err != nil {
+				if auth.Method, buf, err = msgp.ReadStringBytes(buf); err != nil {
 					return
 				}
 
@@ -1,9 +1,15 @@
 package tarantool
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 func TestAuth(t *testing.T) {
@@ -61,3 +67,140 @@ func TestAuth(t *testing.T) {
 	}
 
 }
+
+type testAuthReverse struct{}
+
+func (testAuthReverse) Name() string {
+	return "reverse"
+}
+
+func (testAuthReverse) AppendAuthData(b []byte, salt []byte, password string) ([]byte, error) {
+	data := []byte(password)
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return msgp.AppendBytes(b, data), nil
+}
+
+func (m testAuthReverse) Verify(salt []byte, data []byte, password string) bool {
+	expected, _ := m.AppendAuthData(nil, salt, password)
+	return bytes.Equal(msgp.AppendBytes(nil, data), expected)
+}
+
+func TestAuthMethods(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	RegisterAuthMethod(testAuthReverse{})
+
+	salt := []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	for _, name := range []string{"", AuthChapSha1, AuthPapSha256, "reverse"} {
+		buf, err := (&Auth{User: "tester", Password: "secret", GreetingAuth: salt, Method: name}).MarshalMsg(nil)
+		require.NoError(err, name)
+
+		var q Auth
+		_, err = q.UnmarshalMsg(buf)
+		require.NoError(err, name)
+		assert.Equal("tester", q.User)
+
+		method, ok := getAuthMethod(name)
+		require.True(ok, name)
+		assert.Equal(method.Name(), q.Method)
+		assert.True(method.Verify(salt, q.GreetingAuth, "secret"), name)
+		assert.False(method.Verify(salt, q.GreetingAuth, "wrong"), name)
+	}
+
+	_, err := (&Auth{User: "tester", GreetingAuth: salt, Method: "unknown"}).MarshalMsg(nil)
+	assert.Error(err)
+}
+
+func TestConnectAuthMethods(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	RegisterAuthMethod(testAuthReverse{})
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	defer ln.Close()
+
+	methods := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var s *IprotoServer
+			s = NewIprotoServer("1", func(_ context.Context, q Query) *Result {
+				if auth, ok := q.(*Auth); ok {
+					methods <- auth.Method
+					if !s.VerifyAuth(auth, "secret") {
+						return &Result{ErrorCode: ErrCredsMismatch, Error: NewQueryError(ErrCredsMismatch, "User not found or supplied credentials are invalid")}
+					}
+				}
+				return &Result{}
+			}, nil).WithOptions(&IprotoServerOptions{
+				TLSConfig:    serverConfig,
+				ProtocolInfo: &ProtocolInfo{AuthType: AuthPapSha256},
+			})
+			s.Accept(conn)
+		}
+	}()
+
+	tt := []struct {
+		method   string
+		password string
+		expected string
+		err      bool
+	}{
+		// the method advertised by the server
+		{"", "secret", AuthPapSha256, false},
+		{AuthChapSha1, "secret", AuthChapSha1, false},
+		{AuthChapSha1, "wrong", AuthChapSha1, true},
+		{AuthPapSha256, "wrong", AuthPapSha256, true},
+		{"reverse", "secret", "reverse", false},
+	}
+	for _, tc := range tt {
+		conn, err := Connect("tls://"+ln.Addr().String(), &Options{
+			User:       "tester",
+			Password:   tc.password,
+			AuthMethod: tc.method,
+			TLSConfig:  clientConfig,
+		})
+		assert.Equal(tc.expected, <-methods, tc)
+		if tc.err {
+			assert.Error(err, tc)
+			continue
+		}
+		require.NoError(err, tc)
+		conn.Close()
+	}
+
+	_, err = Connect("tls://"+ln.Addr().String(), &Options{User: "tester", AuthMethod: "unknown", TLSConfig: clientConfig})
+	assert.Error(err)
+}
+
+func TestConnectAuthPapSha256WithoutTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	var methods []string
+	l := newIprotoListenerWithOptions(t, func(_ context.Context, q Query) *Result {
+		if auth, ok := q.(*Auth); ok {
+			methods = append(methods, auth.Method)
+		}
+		return &Result{}
+	}, &IprotoServerOptions{ProtocolInfo: &ProtocolInfo{AuthType: AuthPapSha256}})
+	defer l.Close()
+
+	_, err := Connect(l.Addr(), &Options{User: "tester", AuthMethod: AuthPapSha256})
+	assert.Equal(ErrInsecureAuth, err)
+
+	// the advertised method is not used without TLS
+	conn, err := Connect(l.Addr(), &Options{User: "tester"})
+	if assert.NoError(err) {
+		conn.Close()
+	}
+	assert.Equal([]string{AuthChapSha1}, methods)
+}
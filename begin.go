@@ -0,0 +1,91 @@
+package tarantool
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Begin starts an interactive transaction in a stream.
+// It is available since Tarantool >= 2.10.0
+type Begin struct {
+	Isolation uint
+	Timeout   time.Duration
+}
+
+var _ Query = (*Begin)(nil)
+
+func (q *Begin) GetCommandID() uint {
+	return BeginCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Begin) MarshalMsg(b []byte) (o []byte, err error) {
+	var n uint32
+
+	if q.Isolation != TxnIsolationDefault {
+		n++
+	}
+	if q.Timeout != 0 {
+		n++
+	}
+
+	o = b
+	o = msgp.AppendMapHeader(o, n)
+
+	if q.Isolation != TxnIsolationDefault {
+		o = msgp.AppendUint(o, KeyTxnIsolation)
+		o = msgp.AppendUint(o, q.Isolation)
+	}
+	if q.Timeout != 0 {
+		o = msgp.AppendUint(o, KeyTimeout)
+		o = msgp.AppendFloat64(o, q.Timeout.Seconds())
+	}
+
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Begin) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var i uint32
+	var k uint
+
+	q.Isolation = TxnIsolationDefault
+	q.Timeout = 0
+
+	buf = data
+	if len(buf) == 0 {
+		return
+	}
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeyTxnIsolation:
+			if q.Isolation, buf, err = msgp.ReadUintBytes(buf); err != nil {
+				return
+			}
+		case KeyTimeout:
+			var timeout float64
+			if timeout, buf, err = msgp.ReadFloat64Bytes(buf); err != nil {
+				return
+			}
+			if timeout < 0 {
+				return buf, fmt.Errorf("Begin.Unpack: negative timeout %v", timeout)
+			}
+			q.Timeout = time.Duration(timeout * float64(time.Second))
+		default:
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
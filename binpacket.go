@@ -12,7 +12,7 @@ import (
 
 type BinaryPacket struct {
 	body   []byte
-	header [32]byte
+	header [48]byte
 	pool   *BinaryPacketPool
 	packet Packet
 }
@@ -21,12 +21,16 @@ type UnmarshalBinaryBodyFunc func(*Packet, []byte) error
 
 // WriteTo implements the io.WriterTo interface
 func (pp *BinaryPacket) WriteTo(w io.Writer) (n int64, err error) {
-	h32 := pp.header[:32]
+	hbuf := pp.header[:]
 	body := pp.body
 
-	h := msgp.AppendUint(h32[:0], math.MaxUint32)
+	h := msgp.AppendUint(hbuf[:0], math.MaxUint32)
 	mappos := len(h)
-	h = msgp.AppendMapHeader(h, 3)
+	if pp.packet.StreamID != 0 {
+		h = msgp.AppendMapHeader(h, 4)
+	} else {
+		h = msgp.AppendMapHeader(h, 3)
+	}
 	h = msgp.AppendUint(h, KeyCode)
 	h = msgp.AppendUint(h, math.MaxUint32)
 	syncpos := len(h)
@@ -34,11 +38,15 @@ func (pp *BinaryPacket) WriteTo(w io.Writer) (n int64, err error) {
 	h = msgp.AppendUint64(h, pp.packet.requestID)
 	h = msgp.AppendUint(h, KeySchemaID)
 	h = msgp.AppendUint64(h, pp.packet.SchemaID)
+	if pp.packet.StreamID != 0 {
+		h = msgp.AppendUint(h, KeyStreamID)
+		h = msgp.AppendUint64(h, pp.packet.StreamID)
+	}
 
 	binary.BigEndian.PutUint32(h[syncpos-4:], uint32(pp.packet.Cmd))
 
 	l := len(h) + len(body) - mappos
-	binary.BigEndian.PutUint32(h32[mappos-4:], uint32(l))
+	binary.BigEndian.PutUint32(hbuf[mappos-4:], uint32(l))
 
 	m, err := w.Write(h)
 	n += int64(m)
@@ -56,9 +64,11 @@ func (pp *BinaryPacket) WriteTo(w io.Writer) (n int64, err error) {
 func (pp *BinaryPacket) Reset() {
 	pp.packet.Cmd = OKCommand
 	pp.packet.SchemaID = 0
+	pp.packet.StreamID = 0
 	pp.packet.requestID = 0
 	pp.packet.Result = nil
 	pp.packet.ResultUnmarshalMode = ResultDefaultMode
+	pp.packet.resultDest = nil
 	pp.body = pp.body[:0]
 }
 
@@ -166,16 +176,28 @@ func (pp *BinaryPacket) readRawPacket(r io.Reader) (requestID uint64, err error)
 		return
 	}
 
-	for ; l > 0; l-- {
+	// the request id and the command code are needed to route the packet,
+	// the schema id is checked by the connection
+	pp.packet.SchemaID = 0
+	for found := 0; l > 0 && found < 3; l-- {
 		var cd uint
 		if cd, buf, err = msgp.ReadUintBytes(buf); err != nil {
 			return
 		}
-		if cd == KeySync {
-			requestID, _, err = msgp.ReadUint64Bytes(buf)
-			return
+		switch cd {
+		case KeySync:
+			requestID, buf, err = msgp.ReadUint64Bytes(buf)
+			found++
+		case KeyCode:
+			pp.packet.Cmd, buf, err = msgp.ReadUintBytes(buf)
+			found++
+		case KeySchemaID:
+			pp.packet.SchemaID, buf, err = msgp.ReadUint64Bytes(buf)
+			found++
+		default:
+			buf, err = msgp.Skip(buf)
 		}
-		if buf, err = msgp.Skip(buf); err != nil {
+		if err != nil {
 			return
 		}
 	}
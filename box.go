@@ -39,6 +39,9 @@ type BoxOptions struct {
 
 	LogDir        string
 	LogNamePrefix string
+
+	// UnixSocket makes the box listen on the unix socket in its root instead of the port.
+	UnixSocket bool
 }
 
 var (
@@ -70,6 +73,11 @@ func NewBox(config string, options *BoxOptions) (*Box, error) {
 		options.Host += ":"
 	}
 
+	// the socket is unique for the box, no need to try other ports
+	if options.UnixSocket {
+		options.PortMax = options.PortMin
+	}
+
 	var box *Box
 
 	for port := options.PortMin; port <= options.PortMax; port++ {
@@ -100,6 +108,14 @@ func NewBox(config string, options *BoxOptions) (*Box, error) {
 			}
 		`, "{log}", logPath, -1)
 
+		listen := fmt.Sprintf("%s%d", options.Host, port)
+		addr, listenPort := listen, port
+		if options.UnixSocket {
+			sock := filepath.Join(tmpDir, "tarantool.sock")
+			listen = "unix/:" + sock
+			addr, listenPort = "unix://"+sock, 0
+		}
+
 		initLua += `
 			sendstatus("STARTING")
 
@@ -110,7 +126,7 @@ func NewBox(config string, options *BoxOptions) (*Box, error) {
 			sendstatus("BINDING")
 
 			box.cfg{
-				listen = "{host}{port}",
+				listen = "{listen}",
 			}
 
 			sendstatus("READY")
@@ -120,8 +136,7 @@ func NewBox(config string, options *BoxOptions) (*Box, error) {
 		`
 
 		initLua = fmt.Sprintf("%s\n%s\n%s\n", initLua, config, readyLua)
-		initLua = strings.Replace(initLua, "{host}", options.Host, -1)
-		initLua = strings.Replace(initLua, "{port}", fmt.Sprintf("%d", port), -1)
+		initLua = strings.Replace(initLua, "{listen}", listen, -1)
 		initLua = strings.Replace(initLua, "{root}", tmpDir, -1)
 
 		initLua = fmt.Sprintf(`
@@ -153,8 +168,8 @@ func NewBox(config string, options *BoxOptions) (*Box, error) {
 		box = &Box{
 			Root:       tmpDir,
 			WorkDir:    options.WorkDir,
-			Listen:     fmt.Sprintf("%s%d", options.Host, port),
-			Port:       port,
+			Listen:     addr,
+			Port:       listenPort,
 			cmd:        nil,
 			stopped:    make(chan bool),
 			initLua:    initLua,
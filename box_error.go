@@ -0,0 +1,200 @@
+package tarantool
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// BoxError is the error raised by Tarantool with its whole stack (MP_ERROR).
+// Prev is the cause of the error, errors.Unwrap walks down the stack.
+// It is available since Tarantool >= 2.4.1
+type BoxError struct {
+	Type   string // e.g. "ClientError" or "CustomError"
+	File   string
+	Line   uint64
+	Msg    string
+	Errno  uint64
+	Code   uint
+	Fields map[string]interface{} // additional fields, e.g. custom_type of CustomError
+	Prev   *BoxError
+}
+
+var _ msgp.Extension = (*BoxError)(nil)
+
+func (e *BoxError) Error() string {
+	return e.Msg
+}
+
+// Unwrap returns the cause of the error.
+func (e *BoxError) Unwrap() error {
+	if e.Prev == nil {
+		return nil
+	}
+	return e.Prev
+}
+
+// Is reports whether the target is *BoxError with the same Code and,
+// if the target Type is not empty, the same Type:
+//
+//	errors.Is(res.Error, &BoxError{Code: ErrNoSuchSpace})
+func (e *BoxError) Is(target error) bool {
+	t, ok := target.(*BoxError)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.Type == "" || t.Type == e.Type)
+}
+
+// Depth returns the number of errors in the stack.
+func (e *BoxError) Depth() int {
+	n := 0
+	for ; e != nil; e = e.Prev {
+		n++
+	}
+	return n
+}
+
+// ExtensionType implements msgp.Extension
+func (e *BoxError) ExtensionType() int8 {
+	return ExtError
+}
+
+// Len implements msgp.Extension
+func (e *BoxError) Len() int {
+	return len(appendBoxErrorStack(nil, e))
+}
+
+// MarshalBinaryTo implements msgp.Extension
+func (e *BoxError) MarshalBinaryTo(b []byte) error {
+	copy(b, appendBoxErrorStack(nil, e))
+	return nil
+}
+
+// UnmarshalBinary implements msgp.Extension
+func (e *BoxError) UnmarshalBinary(data []byte) error {
+	be, _, err := unmarshalBoxErrorStack(data)
+	if err != nil {
+		return err
+	}
+	if be == nil {
+		*e = BoxError{}
+	} else {
+		*e = *be
+	}
+	return nil
+}
+
+// appendBoxErrorStack appends MP_ERROR map with the error stack, the error itself goes first.
+func appendBoxErrorStack(o []byte, e *BoxError) []byte {
+	o = msgp.AppendMapHeader(o, 1)
+	o = msgp.AppendUint(o, KeyMPErrorStack)
+	o = msgp.AppendArrayHeader(o, uint32(e.Depth()))
+	for ; e != nil; e = e.Prev {
+		n := uint32(6)
+		if len(e.Fields) != 0 {
+			n++
+		}
+		o = msgp.AppendMapHeader(o, n)
+		o = msgp.AppendUint(o, KeyMPErrorType)
+		o = msgp.AppendString(o, e.Type)
+		o = msgp.AppendUint(o, KeyMPErrorFile)
+		o = msgp.AppendString(o, e.File)
+		o = msgp.AppendUint(o, KeyMPErrorLine)
+		o = msgp.AppendUint64(o, e.Line)
+		o = msgp.AppendUint(o, KeyMPErrorMessage)
+		o = msgp.AppendString(o, e.Msg)
+		o = msgp.AppendUint(o, KeyMPErrorErrno)
+		o = msgp.AppendUint64(o, e.Errno)
+		o = msgp.AppendUint(o, KeyMPErrorCode)
+		o = msgp.AppendUint(o, e.Code)
+		if len(e.Fields) != 0 {
+			o = msgp.AppendUint(o, KeyMPErrorFields)
+			// fields are the decoded msgpack values, so they are always encodable
+			o, _ = msgp.AppendMapStrIntf(o, e.Fields)
+		}
+	}
+	return o
+}
+
+func unmarshalBoxErrorStack(data []byte) (e *BoxError, buf []byte, err error) {
+	var l, n uint32
+	var k uint
+
+	buf = data
+	if l, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; l > 0; l-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+		if k != KeyMPErrorStack {
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+			continue
+		}
+
+		if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+			return
+		}
+
+		var last *BoxError
+		for ; n > 0; n-- {
+			be := &BoxError{}
+			if buf, err = be.unmarshalMsg(buf); err != nil {
+				return
+			}
+			if last == nil {
+				e = be
+			} else {
+				last.Prev = be
+			}
+			last = be
+		}
+	}
+	return
+}
+
+// unmarshalMsg decodes a single error of the stack
+func (e *BoxError) unmarshalMsg(data []byte) (buf []byte, err error) {
+	var l uint32
+	var k uint
+	var fields interface{}
+
+	buf = data
+	if l, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; l > 0; l-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeyMPErrorType:
+			e.Type, buf, err = msgp.ReadStringBytes(buf)
+		case KeyMPErrorFile:
+			e.File, buf, err = msgp.ReadStringBytes(buf)
+		case KeyMPErrorLine:
+			e.Line, buf, err = msgp.ReadUint64Bytes(buf)
+		case KeyMPErrorMessage:
+			e.Msg, buf, err = msgp.ReadStringBytes(buf)
+		case KeyMPErrorErrno:
+			e.Errno, buf, err = msgp.ReadUint64Bytes(buf)
+		case KeyMPErrorCode:
+			e.Code, buf, err = msgp.ReadUintBytes(buf)
+		case KeyMPErrorFields:
+			if fields, buf, err = readIntfBytes(buf); err == nil {
+				e.Fields, _ = fields.(map[string]interface{})
+			}
+		default:
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
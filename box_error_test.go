@@ -0,0 +1,115 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func newTestBoxError() *BoxError {
+	return &BoxError{
+		Type:   "CustomError",
+		File:   "init.lua",
+		Line:   12,
+		Msg:    "balance is too low",
+		Code:   ErrProcLua,
+		Fields: map[string]interface{}{"custom_type": "BalanceError"},
+		Prev: &BoxError{
+			Type: "ClientError",
+			File: "box.cc",
+			Line: 123,
+			Msg:  "Space 'accounts' does not exist",
+			Code: ErrNoSuchSpace,
+		},
+	}
+}
+
+func TestBoxErrorResult(t *testing.T) {
+	require := require.New(t)
+
+	res := &Result{ErrorCode: ErrProcLua, Error: newTestBoxError()}
+	buf, err := res.MarshalMsg(nil)
+	require.NoError(err)
+
+	res2 := &Result{ErrorCode: ErrProcLua}
+	rest, err := res2.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Empty(rest)
+	require.Equal("balance is too low", res2.Error.Error())
+
+	var qe *QueryError
+	require.True(errors.As(res2.Error, &qe))
+	require.Equal(ErrProcLua, qe.Code)
+
+	var boxErr *BoxError
+	require.True(errors.As(res2.Error, &boxErr))
+	require.Equal(newTestBoxError(), boxErr)
+	require.Equal(2, boxErr.Depth())
+
+	require.True(errors.Is(res2.Error, &BoxError{Code: ErrNoSuchSpace}))
+	require.True(errors.Is(res2.Error, &BoxError{Code: ErrProcLua, Type: "CustomError"}))
+	require.False(errors.Is(res2.Error, &BoxError{Code: ErrProcLua, Type: "ClientError"}))
+	require.False(errors.Is(res2.Error, &BoxError{Code: ErrNoSuchIndex}))
+}
+
+func TestBoxErrorFlatMessage(t *testing.T) {
+	require := require.New(t)
+
+	// Tarantool < 2.4.1 sends the message only
+	res := &Result{ErrorCode: ErrNoSuchSpace, Error: errors.New("Space 'accounts' does not exist")}
+	buf, err := res.MarshalMsg(nil)
+	require.NoError(err)
+
+	res2 := &Result{ErrorCode: ErrNoSuchSpace}
+	_, err = res2.UnmarshalMsg(buf)
+	require.NoError(err)
+
+	var boxErr *BoxError
+	require.False(errors.As(res2.Error, &boxErr))
+	require.Equal("Space 'accounts' does not exist", res2.Error.Error())
+}
+
+func TestBoxErrorInTuple(t *testing.T) {
+	require := require.New(t)
+
+	buf := msgp.AppendArrayHeader(nil, 1)
+	buf = msgp.AppendArrayHeader(buf, 2)
+	buf = msgp.AppendInt(buf, 1)
+	buf, err := msgp.AppendExtension(buf, newTestBoxError())
+	require.NoError(err)
+
+	data, rest, err := (&Result{}).UnmarshalTuplesArray(buf, true)
+	require.NoError(err)
+	require.Empty(rest)
+	require.Equal([][]interface{}{{int64(1), newTestBoxError()}}, data)
+}
+
+func TestIprotoServerBoxError(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newIprotoListener(t, func(_ context.Context, q Query) *Result {
+		if _, ok := q.(*Call); ok {
+			return &Result{Error: newTestBoxError()}
+		}
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	res := conn.Exec(context.Background(), &Call{Name: "withdraw"})
+	require.Error(res.Error)
+	assert.Equal(ErrProcLua, res.ErrorCode)
+
+	var boxErr *BoxError
+	require.True(errors.As(res.Error, &boxErr))
+	assert.Equal(newTestBoxError(), boxErr)
+	assert.True(errors.Is(res.Error, &BoxError{Code: ErrNoSuchSpace}))
+}
@@ -1,6 +1,7 @@
 package tarantool
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,3 +21,22 @@ func TestBox(t *testing.T) {
 	defer box.Close()
 
 }
+
+func TestBoxUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	box, err := NewBox("", &BoxOptions{UnixSocket: true})
+	if !assert.NoError(err) {
+		return
+	}
+	defer box.Close()
+
+	conn, err := box.Connect(nil)
+	if !assert.NoError(err) {
+		return
+	}
+	defer conn.Close()
+
+	res := conn.Exec(context.Background(), &Ping{})
+	assert.NoError(res.Error)
+}
@@ -0,0 +1,257 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ChangeOperation is the operation of ChangeEvent.
+type ChangeOperation string
+
+const (
+	ChangeInsert  ChangeOperation = "insert"
+	ChangeReplace ChangeOperation = "replace"
+	ChangeUpdate  ChangeOperation = "update"
+	ChangeUpsert  ChangeOperation = "upsert"
+	ChangeDelete  ChangeOperation = "delete"
+)
+
+// ChangeEvent is a DML request of the replication stream, see ChangeStream.
+type ChangeEvent struct {
+	Operation ChangeOperation
+	SpaceID   uint
+	// Space is the name of the space, it is empty if the space is unknown.
+	Space string
+	// Key is the key of Update and Delete, or the primary key of the tuple if the space is known.
+	Key []interface{}
+	// Tuple is the tuple of Insert, Replace and Upsert.
+	Tuple []interface{}
+	// Ops are the operations of Update and Upsert.
+	Ops        []Operator
+	LSN        uint64
+	InstanceID uint32
+	// Timestamp is the time of the request on the master, it is zero for the snapshot rows.
+	Timestamp time.Time
+}
+
+type changeEventJSON struct {
+	Operation  ChangeOperation `json:"op"`
+	SpaceID    uint            `json:"space_id"`
+	Space      string          `json:"space,omitempty"`
+	Key        []interface{}   `json:"key,omitempty"`
+	Tuple      []interface{}   `json:"tuple,omitempty"`
+	Ops        []interface{}   `json:"ops,omitempty"`
+	LSN        uint64          `json:"lsn"`
+	InstanceID uint32          `json:"instance_id"`
+	Timestamp  *time.Time      `json:"timestamp,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The operations are encoded as Tarantool
+// update operations, e.g. ["=", 1, "value"], the values of the extension types,
+// such as *Decimal or *UUID, as strings, and *Interval as an object of its fields.
+func (e *ChangeEvent) MarshalJSON() ([]byte, error) {
+	j := changeEventJSON{
+		Operation:  e.Operation,
+		SpaceID:    e.SpaceID,
+		Space:      e.Space,
+		Key:        jsonValues(e.Key),
+		Tuple:      jsonValues(e.Tuple),
+		LSN:        e.LSN,
+		InstanceID: e.InstanceID,
+	}
+	for _, op := range e.Ops {
+		j.Ops = append(j.Ops, jsonValues(op.AsTuple()))
+	}
+	if !e.Timestamp.IsZero() {
+		j.Timestamp = &e.Timestamp
+	}
+	return json.Marshal(&j)
+}
+
+// jsonValues converts the values which have no JSON encoding of their own.
+func jsonValues(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+	converted := make([]interface{}, len(values))
+	for i, value := range values {
+		converted[i] = jsonValue(value)
+	}
+	return converted
+}
+
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		return jsonValues(value)
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			converted[k] = jsonValue(v)
+		}
+		return converted
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+	return value
+}
+
+// ChangeStream converts the packets of the replication stream into ChangeEvents.
+// The names and the primary keys of the spaces are taken from the rows of _space and _index
+// passed to Event, e.g. during join, and from the schema of the connection, if it is given.
+// ChangeStream can't be used concurrently.
+type ChangeStream struct {
+	schema *Connection
+	data   *packData
+	// dropped are the spaces deleted from _space, the schema of the connection may still have them
+	dropped map[uint64]bool
+}
+
+// NewChangeStream returns ChangeStream looking up the spaces unknown from the stream
+// in the schema of the connection, which may be nil.
+func NewChangeStream(schema *Connection) *ChangeStream {
+	return &ChangeStream{
+		schema:  schema,
+		data:    newPackData(nil),
+		dropped: make(map[uint64]bool),
+	}
+}
+
+// Event converts the packet into ChangeEvent, nil is returned for the packets
+// which are not DML requests, e.g. heartbeats.
+func (cs *ChangeStream) Event(p *Packet) *ChangeEvent {
+	if p == nil {
+		return nil
+	}
+
+	var (
+		e     = &ChangeEvent{LSN: p.LSN, InstanceID: p.InstanceID, Timestamp: p.Timestamp}
+		space interface{}
+	)
+
+	switch q := p.Request.(type) {
+	case *Insert:
+		e.Operation, space, e.Tuple = ChangeInsert, q.Space, q.Tuple
+	case *Replace:
+		e.Operation, space, e.Tuple = ChangeReplace, q.Space, q.Tuple
+	case *Upsert:
+		e.Operation, space, e.Tuple, e.Ops = ChangeUpsert, q.Space, q.Tuple, q.Set
+	case *Update:
+		e.Operation, space, e.Key, e.Ops = ChangeUpdate, q.Space, changeKey(q.Key, q.KeyTuple), q.Set
+	case *Delete:
+		e.Operation, space, e.Key = ChangeDelete, q.Space, changeKey(q.Key, q.KeyTuple)
+	default:
+		return nil
+	}
+
+	id, err := numberToUint64(space)
+	if err != nil {
+		return nil
+	}
+	e.SpaceID = uint(id)
+
+	switch e.Operation {
+	case ChangeInsert, ChangeReplace:
+		cs.follow(e.SpaceID, e.Tuple)
+	case ChangeDelete:
+		cs.drop(e.SpaceID, e.Key)
+	}
+
+	data, name := cs.lookup(id)
+	if data == nil {
+		return e
+	}
+	e.Space = name
+	if e.Key == nil && e.Tuple != nil {
+		if pk, ok := data.primaryKeyMap[id]; ok {
+			e.Key = make([]interface{}, 0, len(pk))
+			for _, field := range pk {
+				if field < len(e.Tuple) {
+					e.Key = append(e.Key, e.Tuple[field])
+				}
+			}
+		}
+	}
+	return e
+}
+
+// Events converts the packets received from in, it closes the returned channel once in is closed.
+func (cs *ChangeStream) Events(in <-chan *Packet) <-chan *ChangeEvent {
+	out := make(chan *ChangeEvent)
+	go func() {
+		defer close(out)
+		for p := range in {
+			if e := cs.Event(p); e != nil {
+				out <- e
+			}
+		}
+	}()
+	return out
+}
+
+// follow loads the rows of _space and _index into the schema of the stream.
+func (cs *ChangeStream) follow(space uint, tuple []interface{}) {
+	switch space {
+	case SpaceSpace:
+		if len(tuple) > 2 {
+			if _, ok := tuple[2].(string); ok {
+				if id, err := numberToUint64(tuple[0]); err == nil {
+					delete(cs.dropped, id)
+				}
+				cs.data.loadSchema([][]interface{}{tuple}, nil)
+			}
+		}
+	case SpaceIndex:
+		if len(tuple) > 5 {
+			_, named := tuple[2].(string)
+			_, opts := tuple[4].(map[string]interface{})
+			_, parts := tuple[5].([]interface{})
+			if named && opts && parts {
+				cs.data.loadSchema(nil, [][]interface{}{tuple})
+			}
+		}
+	}
+}
+
+// drop removes the space deleted from _space.
+func (cs *ChangeStream) drop(space uint, key []interface{}) {
+	if space != SpaceSpace || len(key) == 0 {
+		return
+	}
+	if id, err := numberToUint64(key[0]); err == nil {
+		cs.data.dropSpace(id)
+		cs.dropped[id] = true
+	}
+}
+
+// lookup returns the schema the space is known in and the name of the space.
+func (cs *ChangeStream) lookup(id uint64) (*packData, string) {
+	if name, ok := cs.data.spaceName(id); ok {
+		return cs.data, name
+	}
+	if cs.schema == nil || cs.dropped[id] {
+		return nil, ""
+	}
+	if data, _ := cs.schema.schema(); data != nil {
+		if name, ok := data.spaceName(id); ok {
+			return data, name
+		}
+	}
+	return nil, ""
+}
+
+func changeKey(key interface{}, keyTuple []interface{}) []interface{} {
+	if keyTuple != nil {
+		return keyTuple
+	}
+	if key == nil {
+		return nil
+	}
+	if tuple, ok := key.([]interface{}); ok {
+		return tuple
+	}
+	return []interface{}{key}
+}
@@ -0,0 +1,103 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeStream(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+	require.NoError(emu.CreateSpace(513, "orders", nil, EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{1}}))
+	addr, err := emu.Start("127.0.0.1:0")
+	require.NoError(err)
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	cs := NewChangeStream(conn)
+	ts := time.Unix(1700000000, 0).UTC()
+	id, err := ParseUUID("c8f0fa1f-da29-438c-a040-393f1126ad39")
+	require.NoError(err)
+
+	in := make(chan *Packet, 16)
+	for _, q := range []Query{
+		// the rows of the snapshot define the space
+		&Insert{Space: SpaceSpace, Tuple: []interface{}{uint64(512), uint64(1), "users", "memtx", uint64(0), map[string]interface{}{}, []interface{}{}}},
+		&Insert{Space: SpaceIndex, Tuple: []interface{}{uint64(512), uint64(0), "primary", "tree", map[string]interface{}{"unique": true}, []interface{}{[]interface{}{uint64(0), "unsigned"}}}},
+		&Insert{Space: uint(512), Tuple: []interface{}{int64(1), "alice", id}},
+		&Update{Space: uint(512), Index: uint(0), Key: int64(1), Set: []Operator{&OpAssign{Field: int64(1), Argument: "bob"}}},
+		&Delete{Space: uint(512), Index: uint(0), KeyTuple: []interface{}{int64(1)}},
+		// the space is known from the schema of the connection
+		&Replace{Space: uint(513), Tuple: []interface{}{"x", int64(7)}},
+		&Upsert{Space: uint(514), Tuple: []interface{}{int64(2)}, Set: []Operator{&OpAdd{Field: int64(1), Argument: 1}}},
+		&Ping{},
+	} {
+		in <- &Packet{Request: q, LSN: 10, InstanceID: 1, Timestamp: ts}
+	}
+	in <- &Packet{Result: &Result{}}
+	close(in)
+
+	var events []*ChangeEvent
+	for e := range cs.Events(in) {
+		events = append(events, e)
+	}
+	require.Len(events, 7)
+
+	users := events[2:5]
+	assert.Equal([]ChangeOperation{ChangeInsert, ChangeUpdate, ChangeDelete}, []ChangeOperation{users[0].Operation, users[1].Operation, users[2].Operation})
+	for _, e := range users {
+		assert.Equal("users", e.Space)
+		assert.Equal(uint(512), e.SpaceID)
+		assert.Equal([]interface{}{int64(1)}, e.Key)
+	}
+
+	assert.Equal("orders", events[5].Space)
+	assert.Equal([]interface{}{int64(7)}, events[5].Key)
+
+	assert.Equal(ChangeUpsert, events[6].Operation)
+	assert.Empty(events[6].Space)
+	assert.Nil(events[6].Key)
+
+	data, err := json.Marshal(users[0])
+	require.NoError(err)
+	assert.JSONEq(`{"op":"insert","space_id":512,"space":"users","key":[1],
+		"tuple":[1,"alice","c8f0fa1f-da29-438c-a040-393f1126ad39"],
+		"lsn":10,"instance_id":1,"timestamp":"2023-11-14T22:13:20Z"}`, string(data))
+
+	data, err = json.Marshal(users[1])
+	require.NoError(err)
+	assert.JSONEq(`{"op":"update","space_id":512,"space":"users","key":[1],"ops":[["=",1,"bob"]],
+		"lsn":10,"instance_id":1,"timestamp":"2023-11-14T22:13:20Z"}`, string(data))
+
+	// the renamed space
+	cs.Event(&Packet{Request: &Replace{Space: SpaceSpace, Tuple: []interface{}{uint64(512), uint64(1), "people", "memtx", uint64(0), map[string]interface{}{}, []interface{}{}}}})
+	e := cs.Event(&Packet{Request: &Insert{Space: uint(512), Tuple: []interface{}{int64(2)}}})
+	assert.Equal("people", e.Space)
+
+	// the values of the extension types
+	e = cs.Event(&Packet{Request: &Insert{Space: uint(512), Tuple: []interface{}{int64(3), NewDecimal(-12345, 2), id, &Interval{Month: 1, Day: -2, Adjust: AdjustLast}}}})
+	data, err = json.Marshal(e)
+	require.NoError(err)
+	assert.JSONEq(`{"op":"insert","space_id":512,"space":"people","key":[3],
+		"tuple":[3,"-123.45","c8f0fa1f-da29-438c-a040-393f1126ad39",
+			{"Year":0,"Month":1,"Week":0,"Day":-2,"Hour":0,"Min":0,"Sec":0,"Nsec":0,"Adjust":2}],
+		"lsn":0,"instance_id":0}`, string(data))
+
+	// the dropped spaces are not resolved, even if the connection knows them
+	cs.Event(&Packet{Request: &Delete{Space: SpaceSpace, Index: uint(0), KeyTuple: []interface{}{uint64(512)}}})
+	cs.Event(&Packet{Request: &Delete{Space: SpaceSpace, Index: uint(0), KeyTuple: []interface{}{uint64(513)}}})
+	e = cs.Event(&Packet{Request: &Insert{Space: uint(512), Tuple: []interface{}{int64(4)}}})
+	assert.Empty(e.Space)
+	assert.Nil(e.Key)
+	e = cs.Event(&Packet{Request: &Replace{Space: uint(513), Tuple: []interface{}{"y", int64(8)}}})
+	assert.Empty(e.Space)
+}
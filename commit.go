@@ -0,0 +1,51 @@
+package tarantool
+
+import "github.com/tinylib/msgp/msgp"
+
+// Commit finishes an interactive transaction in a stream.
+// It is available since Tarantool >= 2.10.0
+type Commit struct {
+}
+
+var _ Query = (*Commit)(nil)
+
+func (q *Commit) GetCommandID() uint {
+	return CommitCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Commit) MarshalMsg(b []byte) ([]byte, error) {
+	return msgp.AppendMapHeader(b, 0), nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Commit) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	return msgp.Skip(data)
+}
+
+// Rollback aborts an interactive transaction in a stream.
+// It is available since Tarantool >= 2.10.0
+type Rollback struct {
+}
+
+var _ Query = (*Rollback)(nil)
+
+func (q *Rollback) GetCommandID() uint {
+	return RollbackCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Rollback) MarshalMsg(b []byte) ([]byte, error) {
+	return msgp.AppendMapHeader(b, 0), nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Rollback) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	return msgp.Skip(data)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,9 @@ var (
 	ErrEmptyDefaultSpace = errors.New("zero-length default space or unnecessary slash in dsn.path")
 	ErrSyncFailed        = errors.New("SYNC failed")
 
+	ErrEmptyUnixSocketPath = errors.New("zero-length unix socket path in dsn")
+	ErrInsecureAuth        = errors.New("pap-sha256 authentication requires TLS")
+
 	versionPrefix = []byte("Tarantool ")
 
 	greetingRegexp = regexp.MustCompile("^Tarantool ([0-9.]+) [(]([^ ]+)[)] ([a-f0-9-]+)")
@@ -42,16 +46,62 @@ type Options struct {
 	PoolMaxPacketSize int
 
 	ResultUnmarshalMode resultUnmarshalMode // Result unmarshal mode for user made requests
+
+	// Reconnect enables background reconnection in Connector once the connection is lost.
+	Reconnect bool
+	// ReconnectDelay is the initial delay between reconnection attempts,
+	// it is doubled after every failed attempt up to MaxReconnectDelay.
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	// MaxReconnects limits the number of consecutive reconnection attempts.
+	// If it is 0, Connector retries forever.
+	MaxReconnects int
+	// FailFast makes Connector return ErrReconnecting immediately
+	// instead of waiting for the connection to be restored.
+	FailFast bool
+	// OnStateChange is called by Connector on connect and Close, and with Reconnect set,
+	// on disconnect and reconnection attempts as well.
+	OnStateChange StateChangeFn
+
+	// TLSConfig enables TLS, it is also enabled by tls:// and ssl:// schemes of DSN.
+	// If ServerName is empty, the host of DSN is verified.
+	TLSConfig *tls.Config
+
+	// AuthMethod is the authentication method, e.g. AuthPapSha256 or the registered one.
+	// By default the method advertised by the server or chap-sha1 is used.
+	AuthMethod string
+
+	// Dialer establishes the network connection instead of net.Dialer, e.g. through a proxy.
+	Dialer DialFn
+	// OnGreeting is called with the greeting of the server before authentication,
+	// the connection is closed if it returns an error.
+	OnGreeting OnGreetingFn
+	// OnConnect is called once the connection is ready and before it is returned,
+	// including reconnects of Connector. It can execute requests on the connection,
+	// e.g. to set up the session; the connection is closed if it returns an error.
+	// It isn't called for Slave.
+	OnConnect OnConnectFn
 }
 
+// DialFn establishes the network connection, see net.Dialer.DialContext.
+type DialFn func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// OnGreetingFn is called on the greeting of the server, see Options.OnGreeting.
+type OnGreetingFn func(greeting *Greeting) error
+
+// OnConnectFn is called on the established connection, see Options.OnConnect.
+type OnConnectFn func(ctx context.Context, conn *Connection) error
+
 type Greeting struct {
 	Version      uint32
+	Protocol     string
 	InstanceUUID string
 	Auth         []byte
 }
 
 type Connection struct {
 	requestID uint64
+	streamID  uint64
 	requests  *requestMap
 	writeChan chan *request // packed messages with header
 	closeOnce sync.Once
@@ -65,6 +115,7 @@ type Connection struct {
 	// options
 	queryTimeout        time.Duration
 	greeting            *Greeting
+	protocolInfo        ProtocolInfo
 	packData            *packData
 	remoteAddr          string
 	firstError          error
@@ -72,6 +123,14 @@ type Connection struct {
 	perf                PerfCount
 	poolMaxPacketSize   int
 	resultUnmarshalMode resultUnmarshalMode
+
+	watchLock sync.Mutex
+	watches   map[string]*watchState
+
+	// packData and schemaID are replaced on schema reload
+	schemaLock   sync.RWMutex
+	schemaID     uint64
+	schemaReload *schemaReload
 }
 
 // Connect to tarantool instance with options using the provided context.
@@ -118,6 +177,13 @@ func connect(ctx context.Context, scheme, addr string, opts Options) (conn *Conn
 
 	go conn.worker()
 
+	if opts.OnConnect != nil {
+		if err = opts.OnConnect(ctx, conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
 	return
 }
 
@@ -143,17 +209,39 @@ func newConn(ctx context.Context, scheme, addr string, opts Options) (conn *Conn
 		perf:                opts.Perf,
 		poolMaxPacketSize:   opts.PoolMaxPacketSize,
 		resultUnmarshalMode: opts.ResultUnmarshalMode,
+		watches:             make(map[string]*watchState),
+	}
+
+	dial := opts.Dialer
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: opts.ConnectTimeout}).DialContext
 	}
 
-	d := &net.Dialer{
-		Timeout: opts.ConnectTimeout,
+	network := scheme
+	if network == "tls" {
+		network = "tcp"
 	}
 
-	conn.tcpConn, err = d.DialContext(ctx, scheme, conn.remoteAddr)
+	dialCtx, cancel := context.WithTimeout(ctx, opts.ConnectTimeout)
+	conn.tcpConn, err = dial(dialCtx, network, conn.remoteAddr)
+	cancel()
 	if err != nil {
 		return nil, err
 	}
 
+	connectDeadline := time.Now().Add(opts.ConnectTimeout)
+	conn.tcpConn.SetDeadline(connectDeadline)
+	// removing deadline deferred
+	defer conn.tcpConn.SetDeadline(time.Time{})
+
+	if scheme == "tls" || opts.TLSConfig != nil {
+		tlsConn := tls.Client(conn.tcpConn, tlsClientConfig(opts.TLSConfig, conn.remoteAddr))
+		conn.tcpConn = tlsConn
+		if err = tlsConn.Handshake(); err != nil {
+			return
+		}
+	}
+
 	if conn.perf.NetRead != nil {
 		conn.ccr = NewCountedReader(conn.tcpConn, conn.perf.NetRead)
 	} else {
@@ -166,17 +254,30 @@ func newConn(ctx context.Context, scheme, addr string, opts Options) (conn *Conn
 		conn.ccw = conn.tcpConn
 	}
 
-	connectDeadline := time.Now().Add(opts.ConnectTimeout)
-	conn.tcpConn.SetDeadline(connectDeadline)
-	// removing deadline deferred
-	defer conn.tcpConn.SetDeadline(time.Time{})
-
 	if conn.greeting, err = parseGreeting(conn.ccr); err != nil {
 		return
 	}
 
+	if opts.OnGreeting != nil {
+		if err = opts.OnGreeting(conn.greeting); err != nil {
+			return
+		}
+	}
+
+	// negotiate protocol features if the server supports it
+	if conn.greeting.Version >= version2_10_0 {
+		if err = conn.identify(); err != nil {
+			return
+		}
+	}
+
 	// try to authenticate if user have been provided
 	if len(opts.User) > 0 {
+		var method string
+		if method, err = conn.authMethod(opts.AuthMethod); err != nil {
+			return
+		}
+
 		requestID := conn.nextID()
 
 		pp := packetPool.GetWithID(requestID)
@@ -185,6 +286,7 @@ func newConn(ctx context.Context, scheme, addr string, opts Options) (conn *Conn
 			User:         opts.User,
 			Password:     opts.Password,
 			GreetingAuth: conn.greeting.Auth,
+			Method:       method,
 		}, conn.packData)
 		if err != nil {
 			conn.releasePacket(pp)
@@ -219,17 +321,95 @@ func newConn(ctx context.Context, scheme, addr string, opts Options) (conn *Conn
 	return
 }
 
-func parseOptions(dsnString string, opts Options) (*url.URL, Options, error) {
-	// remove schema, if present
-	// === for backward compatibility (only tcp despite of user wishes :)
-	dsnString = strings.TrimPrefix(dsnString, "unix:")
-	// ===
+// authMethod returns the method to authenticate with: the given one or the one advertised
+// by the server in IPROTO_ID, chap-sha1 is the default. pap-sha256 requires TLS.
+func (conn *Connection) authMethod(name string) (string, error) {
+	_, secure := conn.tcpConn.(*tls.Conn)
+
+	if name == "" {
+		name = conn.protocolInfo.AuthType
+		if _, ok := getAuthMethod(name); !ok || (name == AuthPapSha256 && !secure) {
+			name = AuthChapSha1
+		}
+	}
+
+	if _, ok := getAuthMethod(name); !ok {
+		return "", fmt.Errorf("unknown auth method %#v", name)
+	}
+	if name == AuthPapSha256 && !secure {
+		return "", ErrInsecureAuth
+	}
+	return name, nil
+}
+
+// identify sends IPROTO_ID request and stores the negotiated protocol version and features.
+func (conn *Connection) identify() (err error) {
+	client := ProtocolInfo{Version: ProtocolVersion, Features: clientFeatures}
+	requestID := conn.nextID()
+
+	pp := packetPool.GetWithID(requestID)
+	if err = pp.packMsg(&ID{client}, conn.packData); err != nil {
+		conn.releasePacket(pp)
+		return
+	}
+
+	_, err = pp.WriteTo(conn.ccw)
+	conn.releasePacket(pp)
+	if err != nil {
+		return
+	}
+
+	pp = packetPool.Get()
+	defer conn.releasePacket(pp)
+
+	if _, err = pp.ReadFrom(conn.ccr); err != nil {
+		return
+	}
+
+	response := &pp.packet
+	buf, err := response.UnmarshalBinaryHeader(pp.body)
+	if err != nil {
+		return
+	}
+	if response.requestID != requestID {
+		return ErrSyncFailed
+	}
+
+	if response.Cmd != OKCommand {
+		if _, err = response.UnmarshalBinaryBody(buf); err != nil {
+			return
+		}
+		// the server doesn't know IPROTO_ID: no optional features
+		if response.Result.ErrorCode == ErrUnknownRequestType {
+			return nil
+		}
+		return response.Result.Error
+	}
 
-	// tcp is the default scheme
+	server := &IDResponse{}
+	if _, err = server.UnmarshalMsg(buf); err != nil {
+		return
+	}
+	conn.protocolInfo = client.intersect(server.ProtocolInfo)
+	return nil
+}
+
+func parseOptions(dsnString string, opts Options) (*url.URL, Options, error) {
+	// tcp is the default scheme, a path is the unix socket
 	switch {
 	case strings.HasPrefix(dsnString, "tcp://"):
+	case strings.HasPrefix(dsnString, "tls://"):
+	case strings.HasPrefix(dsnString, "ssl://"):
+		dsnString = "tls" + strings.TrimPrefix(dsnString, "ssl")
+	case strings.HasPrefix(dsnString, "unix:/"):
+	case strings.HasPrefix(dsnString, "unix:"):
+		// === for backward compatibility: unix:host:port is tcp
+		dsnString = "tcp://" + strings.TrimPrefix(dsnString, "unix:")
+		// ===
 	case strings.HasPrefix(dsnString, "//"):
 		dsnString = "tcp:" + dsnString
+	case strings.HasPrefix(dsnString, "/"):
+		dsnString = "unix://" + dsnString
 	default:
 		dsnString = "tcp://" + dsnString
 	}
@@ -238,6 +418,19 @@ func parseOptions(dsnString string, opts Options) (*url.URL, Options, error) {
 		return dsn, opts, err
 	}
 
+	if dsn.Scheme == "unix" {
+		if dsn.Host != "" {
+			// === for backward compatibility: unix://host is tcp
+			dsn.Scheme = "tcp"
+			// ===
+		} else if dsn.Path == "" {
+			return nil, opts, ErrEmptyUnixSocketPath
+		} else {
+			// the path is the address of the socket, not the default space
+			dsn.Host, dsn.Path = dsn.Path, ""
+		}
+	}
+
 	if len(opts.User) == 0 {
 		if user := dsn.User; user != nil {
 			opts.User = user.Username()
@@ -264,10 +457,37 @@ func parseOptions(dsnString string, opts Options) (*url.URL, Options, error) {
 	if opts.QueryTimeout.Nanoseconds() == 0 {
 		opts.QueryTimeout = DefaultQueryTimeout
 	}
+	if opts.ReconnectDelay.Nanoseconds() == 0 {
+		opts.ReconnectDelay = DefaultReconnectDelay
+	}
+	if opts.MaxReconnectDelay < opts.ReconnectDelay {
+		opts.MaxReconnectDelay = DefaultMaxReconnectDelay
+		if opts.MaxReconnectDelay < opts.ReconnectDelay {
+			opts.MaxReconnectDelay = opts.ReconnectDelay
+		}
+	}
 
 	return dsn, opts, nil
 }
 
+// tlsClientConfig returns the config verifying the host of the address unless ServerName is set.
+func tlsClientConfig(config *tls.Config, addr string) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	if config.ServerName != "" {
+		return config
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	config = config.Clone()
+	config.ServerName = host
+	return config
+}
+
 func parseGreeting(r io.Reader) (*Greeting, error) {
 	greeting := make([]byte, 128)
 
@@ -281,14 +501,16 @@ func parseGreeting(r io.Reader) (*Greeting, error) {
 		return nil, err
 	}
 
-	uuid := ""
+	uuid, protocol := "", ""
 	m := greetingRegexp.FindAllSubmatch(greeting[:64], -1)
 	if len(m) > 0 && len(m[0]) > 3 {
+		protocol = string(m[0][2])
 		uuid = string(m[0][3])
 	}
 
 	return &Greeting{
 		Version:      version,
+		Protocol:     protocol,
 		Auth:         greeting[64:108],
 		InstanceUUID: uuid,
 	}, nil
@@ -351,10 +573,12 @@ func (conn *Connection) pullSchema() (err error) {
 			return nil, response.Result.Error
 		}
 
+		conn.schemaID = response.SchemaID
+
 		return response.Result, nil
 	}
 
-	res, err := request(&Select{
+	spaces, err := request(&Select{
 		Space:    ViewSpace,
 		Key:      0,
 		Iterator: IterAll,
@@ -363,12 +587,7 @@ func (conn *Connection) pullSchema() (err error) {
 		return
 	}
 
-	for _, space := range res.Data {
-		spaceID, _ := conn.packData.spaceNo(space[0])
-		conn.packData.spaceMap[space[2].(string)] = spaceID
-	}
-
-	res, err = request(&Select{
+	indexes, err := request(&Select{
 		Space:    ViewIndex,
 		Key:      0,
 		Iterator: IterAll,
@@ -377,41 +596,7 @@ func (conn *Connection) pullSchema() (err error) {
 		return
 	}
 
-	for _, index := range res.Data {
-		spaceID, _ := conn.packData.fieldNo(index[0])
-		indexID, _ := conn.packData.fieldNo(index[1])
-		indexName := index[2].(string)
-		indexAttr := index[4].(map[string]interface{}) // e.g: {"unique": true}
-		indexFields := index[5].([]interface{})        // e.g: [[0 num] [1 str]]
-
-		indexSpaceMap, exists := conn.packData.indexMap[spaceID]
-		if !exists {
-			indexSpaceMap = make(map[string]uint64)
-			conn.packData.indexMap[spaceID] = indexSpaceMap
-		}
-		indexSpaceMap[indexName] = indexID
-
-		// build list of primary key field numbers for this space, if the PK is detected
-		if indexAttr != nil && indexID == 0 {
-			if unique, ok := indexAttr["unique"]; ok && unique.(bool) {
-				pk := make([]int, len(indexFields))
-				for i := range indexFields {
-					switch descr := indexFields[i].(type) {
-					case []interface{}:
-						f, _ := conn.packData.fieldNo(descr[0])
-						pk[i] = int(f)
-					case map[string]interface{}:
-						f, _ := conn.packData.fieldNo(descr["field"])
-						pk[i] = int(f)
-					default:
-						panic("invalid index field format")
-					}
-				}
-				conn.packData.primaryKeyMap[spaceID] = pk
-			}
-		}
-	}
-
+	conn.packData.loadSchema(spaces.Data, indexes.Data)
 	return
 }
 
@@ -435,17 +620,49 @@ func (conn *Connection) GetPrimaryKeyFields(space interface{}) ([]int, bool) {
 	var spaceID uint64
 	var err error
 
-	if conn.packData == nil {
+	data, _ := conn.schema()
+	if data == nil {
 		return nil, false
 	}
-	if spaceID, err = conn.packData.spaceNo(space); err != nil {
+	if spaceID, err = data.spaceNo(space); err != nil {
 		return nil, false
 	}
 
-	f, ok := conn.packData.primaryKeyMap[spaceID]
+	f, ok := data.primaryKeyMap[spaceID]
 	return f, ok
 }
 
+// GetSpaceFormat returns the format of the space as it was on connect.
+func (conn *Connection) GetSpaceFormat(space interface{}) ([]SpaceField, bool) {
+	var spaceID uint64
+	var err error
+
+	data, _ := conn.schema()
+	if data == nil {
+		return nil, false
+	}
+	if spaceID, err = data.spaceNo(space); err != nil {
+		return nil, false
+	}
+
+	f, ok := data.formatMap[spaceID]
+	return f, ok
+}
+
+// TuplesToMaps converts tuples of the space to maps keyed by field names.
+func (conn *Connection) TuplesToMaps(space interface{}, tuples [][]interface{}) ([]map[string]interface{}, error) {
+	format, ok := conn.GetSpaceFormat(space)
+	if !ok {
+		return nil, fmt.Errorf("no format defined for space %#v", space)
+	}
+
+	maps := make([]map[string]interface{}, len(tuples))
+	for i, tuple := range tuples {
+		maps[i] = TupleToMap(tuple, format)
+	}
+	return maps, nil
+}
+
 func (conn *Connection) Close() {
 	conn.stop()
 	<-conn.closed
@@ -468,6 +685,14 @@ func (conn *Connection) InstanceUUID() string {
 	return conn.greeting.InstanceUUID
 }
 
+// ProtocolInfo returns the protocol version and features negotiated with IPROTO_ID.
+// It is empty if the server doesn't support IPROTO_ID (Tarantool < 2.10.0).
+func (conn *Connection) ProtocolInfo() ProtocolInfo {
+	info := conn.protocolInfo
+	info.Features = append([]ProtocolFeature(nil), info.Features...)
+	return info
+}
+
 func (conn *Connection) releasePacket(pp *BinaryPacket) {
 	if conn.poolMaxPacketSize == 0 || conn.poolMaxPacketSize < cap(pp.body) {
 		pp.Release()
@@ -617,6 +842,24 @@ READER_LOOP:
 			conn.perf.NetPacketsIn.Add(1)
 		}
 
+		if pp.packet.SchemaID != 0 && pp.packet.SchemaID > conn.SchemaID() {
+			conn.startSchemaReload()
+		}
+
+		if pp.packet.Cmd == EventCommand {
+			err = pp.packet.UnmarshalBinary(pp.body)
+			event, ok := pp.packet.Request.(*Event)
+			conn.releasePacket(pp)
+			pp = nil
+			if err != nil {
+				break READER_LOOP
+			}
+			if ok {
+				conn.handleEvent(event)
+			}
+			continue
+		}
+
 		req := conn.requests.Pop(requestID)
 		if req == nil {
 			conn.releasePacket(pp)
@@ -629,6 +872,7 @@ READER_LOOP:
 		}
 
 		pp.packet.ResultUnmarshalMode = req.resultMode
+		pp.packet.resultDest = req.resultDest
 		res := AsyncResult{0, nil, pp, conn, req.opaque}
 
 		select {
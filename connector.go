@@ -2,15 +2,50 @@ package tarantool
 
 import (
 	"context"
-	"net/url"
+	"math/rand"
 	"sync"
+	"time"
 )
 
+// ConnState describes the state of the connection managed by Connector.
+type ConnState int
+
+const (
+	StateDisconnected ConnState = iota
+	StateConnected
+	StateReconnecting
+	StateClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// StateChangeFn is called by Connector every time the state of its connection changes.
+// The err argument holds the reason of disconnection, if known.
+type StateChangeFn func(c *Connector, state ConnState, err error)
+
 type Connector struct {
 	sync.Mutex
 	RemoteAddr string
 	options    Options
 	conn       *Connection
+	scheme     string
+	state      ConnState
+	replaced   chan struct{} // closed when the current connection is replaced or abandoned
+	done       chan struct{} // closed by Close to stop background reconnection
+	// watchers registered on the current connection by the ones returned to user
+	watchers map[*Watcher]*Watcher
 }
 
 // New Connector instance.
@@ -22,23 +57,41 @@ func New(dsnString string, options *Options) *Connector {
 }
 
 // Connect returns existing connection or will establish another one using the provided context.
+// If Options.Reconnect is set and the connection is being restored in background,
+// Connect waits for it unless Options.FailFast is set, in which case ErrReconnecting is returned.
+// ErrConnectionClosed is returned once Close has been called.
 func (c *Connector) ConnectContext(ctx context.Context) (conn *Connection, err error) {
-	c.Lock()
-	defer c.Unlock()
+	for {
+		c.Lock()
+		if c.state == StateClosed {
+			c.Unlock()
+			return nil, ErrConnectionClosed
+		}
+		if !c.options.Reconnect || c.conn == nil || !c.conn.IsClosed() {
+			var established bool
+			conn, established, err = c.connect(ctx)
+			onStateChange := c.options.OnStateChange
+			c.Unlock()
+			if established {
+				c.notify(onStateChange, StateConnected, nil)
+			}
+			return conn, err
+		}
 
-	if c.conn == nil || c.conn.IsClosed() {
-		var dsn *url.URL
-		dsn, c.options, err = parseOptions(c.RemoteAddr, c.options)
-		if err != nil {
-			return nil, err
+		// connection is lost and is being restored by the supervisor
+		if c.options.FailFast {
+			c.Unlock()
+			return nil, ErrReconnecting
 		}
-		// clear possible user:pass in order to log c.RemoteAddr securely
-		c.RemoteAddr = dsn.Host
-		c.conn, err = connect(ctx, dsn.Scheme, dsn.Host, c.options)
-	}
-	conn = c.conn
+		replaced := c.replaced
+		c.Unlock()
 
-	return conn, err
+		select {
+		case <-replaced:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
 
 // Connect returns existing connection or will establish another one.
@@ -46,12 +99,175 @@ func (c *Connector) Connect() (conn *Connection, err error) {
 	return c.ConnectContext(context.Background())
 }
 
+// State returns the current state of the underlying connection.
+func (c *Connector) State() ConnState {
+	c.Lock()
+	defer c.Unlock()
+
+	// the lost connection is restored by the next Connect
+	if !c.options.Reconnect && c.state == StateConnected && (c.conn == nil || c.conn.IsClosed()) {
+		return StateDisconnected
+	}
+	return c.state
+}
+
 // Close underlying connection.
 func (c *Connector) Close() {
 	c.Lock()
-	defer c.Unlock()
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	if c.replaced != nil {
+		close(c.replaced)
+		c.replaced = nil
+	}
 	if c.conn != nil && !c.conn.IsClosed() {
 		c.conn.Close()
 	}
 	c.conn = nil
+	closed := c.state != StateClosed
+	onStateChange := c.options.OnStateChange
+	c.state = StateClosed
+	c.Unlock()
+
+	if closed {
+		c.notify(onStateChange, StateClosed, nil)
+	}
+}
+
+// connect must be called with the lock held.
+// It reports whether a new connection has been established.
+func (c *Connector) connect(ctx context.Context) (*Connection, bool, error) {
+	if c.conn != nil && !c.conn.IsClosed() {
+		return c.conn, false, nil
+	}
+
+	if err := c.resolve(); err != nil {
+		return nil, false, err
+	}
+
+	conn, err := connect(ctx, c.scheme, c.RemoteAddr, c.options)
+	if err != nil {
+		c.conn = nil
+		return nil, false, err
+	}
+	c.conn = conn
+	c.rewatch(conn)
+	c.state = StateConnected
+
+	if c.options.Reconnect {
+		if c.done == nil {
+			c.done = make(chan struct{})
+		}
+		c.replaced = make(chan struct{})
+		go c.supervise(conn, c.replaced, c.done)
+	}
+	return conn, true, nil
+}
+
+// resolve parses the DSN once, so the scheme is kept for the next connections.
+// It must be called with the lock held.
+func (c *Connector) resolve() error {
+	if c.scheme != "" {
+		return nil
+	}
+
+	dsn, opts, err := parseOptions(c.RemoteAddr, c.options)
+	if err != nil {
+		return err
+	}
+	c.options = opts
+	// clear possible user:pass in order to log c.RemoteAddr securely
+	c.RemoteAddr = dsn.Host
+	c.scheme = dsn.Scheme
+	return nil
+}
+
+// supervise waits for the connection to be lost and restores it with exponential backoff.
+func (c *Connector) supervise(conn *Connection, replaced, done chan struct{}) {
+	select {
+	case <-conn.closed:
+	case <-done:
+		return
+	}
+
+	c.Lock()
+	if c.conn != conn {
+		c.Unlock()
+		return
+	}
+	c.state = StateReconnecting
+	scheme, addr, opts := c.scheme, c.RemoteAddr, c.options
+	c.Unlock()
+
+	c.notify(opts.OnStateChange, StateDisconnected, conn.getError())
+	c.notify(opts.OnStateChange, StateReconnecting, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	delay := opts.ReconnectDelay
+	for attempt := 1; ; attempt++ {
+		newConn, err := connect(ctx, scheme, addr, opts)
+		if err == nil {
+			c.Lock()
+			if c.conn != conn {
+				// Close has been called in the meantime
+				c.Unlock()
+				newConn.Close()
+				return
+			}
+			c.conn = newConn
+			c.rewatch(newConn)
+			c.state = StateConnected
+			c.replaced = make(chan struct{})
+			go c.supervise(newConn, c.replaced, done)
+			c.Unlock()
+			close(replaced)
+
+			c.notify(opts.OnStateChange, StateConnected, nil)
+			return
+		}
+
+		if opts.MaxReconnects > 0 && attempt >= opts.MaxReconnects {
+			c.Lock()
+			if c.conn == conn {
+				c.conn = nil
+				c.replaced = nil
+				c.state = StateDisconnected
+				c.Unlock()
+				close(replaced)
+				c.notify(opts.OnStateChange, StateDisconnected, err)
+				return
+			}
+			c.Unlock()
+			return
+		}
+
+		// full jitter on the upper half of the current delay
+		sleep := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-time.After(sleep):
+		case <-done:
+			return
+		}
+
+		if delay *= 2; delay > opts.MaxReconnectDelay {
+			delay = opts.MaxReconnectDelay
+		}
+	}
+}
+
+func (c *Connector) notify(fn StateChangeFn, state ConnState, err error) {
+	if fn != nil {
+		fn(c, state, err)
+	}
 }
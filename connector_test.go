@@ -0,0 +1,185 @@
+package tarantool
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// iprotoListener accepts connections and serves every one of them with a new IprotoServer.
+type iprotoListener struct {
+	sync.Mutex
+	ln      net.Listener
+	servers []*IprotoServer
+}
+
+func newIprotoListener(t *testing.T, handler QueryHandler) *iprotoListener {
+	return newIprotoListenerWithOptions(t, handler, nil)
+}
+
+func newIprotoListenerWithOptions(t *testing.T, handler QueryHandler, opts *IprotoServerOptions) *iprotoListener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	l := &iprotoListener{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s := NewIprotoServer("1", handler, nil).WithOptions(opts)
+			l.Lock()
+			l.servers = append(l.servers, s)
+			l.Unlock()
+			s.Accept(conn)
+		}
+	}()
+	return l
+}
+
+func (l *iprotoListener) Addr() string {
+	return l.ln.Addr().String()
+}
+
+// Drop shuts down all accepted sessions but keeps listening.
+func (l *iprotoListener) Drop() {
+	l.Lock()
+	defer l.Unlock()
+	for _, s := range l.servers {
+		s.Shutdown()
+	}
+	l.servers = nil
+}
+
+func (l *iprotoListener) Close() {
+	l.ln.Close()
+	l.Drop()
+}
+
+func TestConnectorReconnect(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newIprotoListener(t, func(context.Context, Query) *Result {
+		return &Result{}
+	})
+	defer l.Close()
+
+	states := make(chan ConnState, 16)
+	c := New(l.Addr(), &Options{
+		Reconnect:      true,
+		ReconnectDelay: 10 * time.Millisecond,
+		OnStateChange: func(_ *Connector, state ConnState, _ error) {
+			states <- state
+		},
+	})
+	defer c.Close()
+
+	conn, err := c.Connect()
+	require.NoError(err)
+	assert.Equal(StateConnected, <-states)
+	assert.Equal(StateConnected, c.State())
+
+	l.Drop()
+	<-conn.closed
+
+	assert.Equal(StateDisconnected, <-states)
+	assert.Equal(StateReconnecting, <-states)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn2, err := c.ConnectContext(ctx)
+	require.NoError(err)
+	assert.NotEqual(conn, conn2)
+	assert.Equal(StateConnected, <-states)
+
+	res := conn2.Exec(context.Background(), &Ping{})
+	assert.NoError(res.Error)
+
+	c.Close()
+	assert.Equal(StateClosed, <-states)
+}
+
+func TestConnectorFailFast(t *testing.T) {
+	require := require.New(t)
+
+	l := newIprotoListener(t, func(context.Context, Query) *Result {
+		return &Result{}
+	})
+
+	c := New(l.Addr(), &Options{
+		Reconnect:      true,
+		ReconnectDelay: time.Hour,
+		MaxReconnects:  2,
+		FailFast:       true,
+	})
+	defer c.Close()
+
+	conn, err := c.Connect()
+	require.NoError(err)
+
+	l.Close()
+	<-conn.closed
+
+	_, err = c.Connect()
+	require.Equal(ErrReconnecting, err)
+}
+
+func TestConnectorClose(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newIprotoListener(t, func(context.Context, Query) *Result {
+		return &Result{}
+	})
+	defer l.Close()
+
+	for _, reconnect := range []bool{false, true} {
+		states := make(chan ConnState, 16)
+		c := New(l.Addr(), &Options{
+			Reconnect:      reconnect,
+			ReconnectDelay: time.Hour,
+			OnStateChange: func(_ *Connector, state ConnState, _ error) {
+				states <- state
+			},
+		})
+
+		// the first connect is reported in both modes
+		_, err := c.Connect()
+		require.NoError(err)
+		assert.Equal(StateConnected, <-states, reconnect)
+
+		c.Close()
+		assert.Equal(StateClosed, <-states, reconnect)
+		_, err = c.Connect()
+		assert.Equal(ErrConnectionClosed, err, reconnect)
+	}
+
+	// the callers waiting for the connection to be restored don't dial again
+	c := New(l.Addr(), &Options{Reconnect: true, ReconnectDelay: time.Hour})
+	conn, err := c.Connect()
+	require.NoError(err)
+	l.Close()
+	<-conn.closed
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := c.Connect()
+		waiting <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	c.Close()
+
+	select {
+	case err := <-waiting:
+		assert.Equal(ErrConnectionClosed, err)
+	case <-time.After(time.Second):
+		t.Fatal("Connect is not released")
+	}
+}
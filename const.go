@@ -16,12 +16,21 @@ const (
 	EvalCommand          = uint(8)
 	UpsertCommand        = uint(9)
 	Call17Command        = uint(10) // Tarantool >= 1.7.2
+	ExecuteCommand       = uint(11) // Tarantool >= 2.0.0
+	PrepareCommand       = uint(13) // Tarantool >= 2.3.1
+	BeginCommand         = uint(14) // Tarantool >= 2.10.0
+	CommitCommand        = uint(15) // Tarantool >= 2.10.0
+	RollbackCommand      = uint(16) // Tarantool >= 2.10.0
 	PingCommand          = uint(64)
 	JoinCommand          = uint(65)
 	SubscribeCommand     = uint(66)
 	VoteCommand          = uint(68) // Tarantool >= 1.9.0
 	FetchSnapshotCommand = uint(69) // for starting anonymous replication. Tarantool >= 2.3.1
 	RegisterCommand      = uint(70) // for leaving anonymous replication (anon => normal replica). Tarantool >= 2.3.1
+	IDCommand            = uint(73) // protocol features negotiation. Tarantool >= 2.10.0
+	WatchCommand         = uint(74) // Tarantool >= 2.10.0
+	UnwatchCommand       = uint(75) // Tarantool >= 2.10.0
+	EventCommand         = uint(76) // Tarantool >= 2.10.0
 	ErrorFlag            = uint(0x8000)
 )
 
@@ -33,6 +42,7 @@ const (
 	KeyTimestamp      = uint(0x04)
 	KeySchemaID       = uint(0x05)
 	KeyVersionID      = uint(0x06)
+	KeyStreamID       = uint(0x0a) // Tarantool >= 2.10.0
 	KeySpaceNo        = uint(0x10)
 	KeyIndexNo        = uint(0x11)
 	KeyLimit          = uint(0x12)
@@ -48,9 +58,71 @@ const (
 	KeyExpression     = uint(0x27)
 	KeyDefTuple       = uint(0x28)
 	KeyBallot         = uint(0x29) // Tarantool >= 1.9.0
+	KeyOptions        = uint(0x2b)
 	KeyData           = uint(0x30)
 	KeyError          = uint(0x31)
+	KeyMetadata       = uint(0x32)
+	KeyBindMetadata   = uint(0x33)
+	KeyBindCount      = uint(0x34)
+	KeySQLText        = uint(0x40)
+	KeySQLBind        = uint(0x41)
+	KeySQLInfo        = uint(0x42)
+	KeyStmtID         = uint(0x43)
 	KeyReplicaAnon    = uint(0x50) // Tarantool >= 2.3.1
+	KeyErrorStack     = uint(0x52) // Tarantool >= 2.4.1
+	KeyVersion        = uint(0x54) // Tarantool >= 2.10.0
+	KeyFeatures       = uint(0x55) // Tarantool >= 2.10.0
+	KeyTimeout        = uint(0x56) // Tarantool >= 2.10.0
+	KeyEventKey       = uint(0x57) // Tarantool >= 2.10.0
+	KeyEventData      = uint(0x58) // Tarantool >= 2.10.0
+	KeyTxnIsolation   = uint(0x59) // Tarantool >= 2.10.0
+	KeyAuthType       = uint(0x5b) // Tarantool >= 2.11.0
+)
+
+// SQL column metadata keys
+const (
+	KeyFieldName            = uint(0x00)
+	KeyFieldType            = uint(0x01)
+	KeyFieldColl            = uint(0x02)
+	KeyFieldIsNullable      = uint(0x03)
+	KeyFieldIsAutoincrement = uint(0x04)
+	KeyFieldSpan            = uint(0x05)
+)
+
+// MP_ERROR keys
+const (
+	KeyMPErrorStack = uint(0x00)
+
+	KeyMPErrorType    = uint(0x00)
+	KeyMPErrorFile    = uint(0x01)
+	KeyMPErrorLine    = uint(0x02)
+	KeyMPErrorMessage = uint(0x03)
+	KeyMPErrorErrno   = uint(0x04)
+	KeyMPErrorCode    = uint(0x05)
+	KeyMPErrorFields  = uint(0x06)
+)
+
+// MessagePack extension types used by Tarantool
+const (
+	ExtDecimal  = int8(1) // Tarantool >= 2.2.1
+	ExtUUID     = int8(2) // Tarantool >= 2.4.1
+	ExtError    = int8(3) // Tarantool >= 2.4.1
+	ExtDatetime = int8(4) // Tarantool >= 2.10.0
+	ExtInterval = int8(6) // Tarantool >= 2.10.0
+)
+
+// SQL info keys
+const (
+	KeySQLInfoRowCount         = uint(0x00)
+	KeySQLInfoAutoincrementIDs = uint(0x01)
+)
+
+// Transaction isolation levels for the BEGIN command
+const (
+	TxnIsolationDefault       = uint(0) // box.cfg.txn_isolation
+	TxnIsolationReadCommitted = uint(1)
+	TxnIsolationReadConfirmed = uint(2)
+	TxnIsolationBestEffort    = uint(3)
 )
 
 const (
@@ -370,6 +442,8 @@ const (
 
 const (
 	ServerIdent = "Tarantool 1.6.8 (Binary)"
+	// ServerIdentID is sent by IprotoServer which answers IPROTO_ID requests
+	ServerIdentID = "Tarantool 2.10.0 (Binary)"
 )
 
 // Consts for Tarantool features which require version check
@@ -382,5 +456,6 @@ const (
 	version2_5_1  = uint32(132353) // VersionID(2, 5, 1)
 	version2_8_0  = uint32(133120) // VersionID(2, 8, 0)
 	version2_9_0  = uint32(133376) // VersionID(2, 9, 0)
+	version2_10_0 = uint32(133632) // VersionID(2, 10, 0), IPROTO_ID
 	version2_11_0 = uint32(133888) // VersionID(2, 11, 0)
 )
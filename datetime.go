@@ -0,0 +1,218 @@
+package tarantool
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+var (
+	// ErrBadDatetime is returned by decoding of malformed MP_DATETIME.
+	ErrBadDatetime = errors.New("invalid datetime")
+	// ErrBadInterval is returned by decoding of malformed MP_INTERVAL.
+	ErrBadInterval = errors.New("invalid interval")
+)
+
+// Datetime is Tarantool datetime (MP_DATETIME).
+// Pass *Datetime in tuples and keys, decoded values are *Datetime as well.
+// It is available since Tarantool >= 2.10.0
+type Datetime struct {
+	// Time is in the fixed zone of the Tarantool tzoffset or in UTC.
+	Time time.Time
+	// TzIndex is the index of the Tarantool timezone name, it is kept as is.
+	TzIndex int16
+}
+
+var _ msgp.Extension = (*Datetime)(nil)
+
+// NewDatetime returns Datetime for the given time.
+func NewDatetime(t time.Time) *Datetime {
+	return &Datetime{Time: t}
+}
+
+func (d *Datetime) String() string {
+	return d.Time.String()
+}
+
+// ExtensionType implements msgp.Extension
+func (d *Datetime) ExtensionType() int8 {
+	return ExtDatetime
+}
+
+func (d *Datetime) tzOffset() int16 {
+	_, offset := d.Time.Zone()
+	return int16(offset / 60)
+}
+
+// Len implements msgp.Extension
+func (d *Datetime) Len() int {
+	// seconds only, or seconds followed by nanoseconds, tzoffset and tzindex
+	if d.Time.Nanosecond() == 0 && d.tzOffset() == 0 && d.TzIndex == 0 {
+		return 8
+	}
+	return 16
+}
+
+// MarshalBinaryTo implements msgp.Extension
+func (d *Datetime) MarshalBinaryTo(b []byte) error {
+	binary.LittleEndian.PutUint64(b, uint64(d.Time.Unix()))
+	if len(b) >= 16 {
+		binary.LittleEndian.PutUint32(b[8:], uint32(d.Time.Nanosecond()))
+		binary.LittleEndian.PutUint16(b[12:], uint16(d.tzOffset()))
+		binary.LittleEndian.PutUint16(b[14:], uint16(d.TzIndex))
+	}
+	return nil
+}
+
+// UnmarshalBinary implements msgp.Extension
+func (d *Datetime) UnmarshalBinary(data []byte) error {
+	var nsec int32
+	var offset int16
+
+	switch len(data) {
+	case 8:
+		d.TzIndex = 0
+	case 16:
+		nsec = int32(binary.LittleEndian.Uint32(data[8:]))
+		offset = int16(binary.LittleEndian.Uint16(data[12:]))
+		d.TzIndex = int16(binary.LittleEndian.Uint16(data[14:]))
+	default:
+		return ErrBadDatetime
+	}
+
+	d.Time = time.Unix(int64(binary.LittleEndian.Uint64(data)), int64(nsec)).UTC()
+	if offset != 0 {
+		d.Time = d.Time.In(time.FixedZone("", int(offset)*60))
+	}
+	return nil
+}
+
+// IntervalAdjust defines how the day of month is adjusted by Tarantool
+// when the interval is added to datetime.
+type IntervalAdjust int64
+
+const (
+	AdjustExcess IntervalAdjust = 0 // overflow to the next month
+	AdjustNone   IntervalAdjust = 1 // clamp to the last day of month, default in Tarantool
+	AdjustLast   IntervalAdjust = 2 // the last day of month stays the last one
+)
+
+// Interval keys
+const (
+	keyIntervalYear = iota
+	keyIntervalMonth
+	keyIntervalWeek
+	keyIntervalDay
+	keyIntervalHour
+	keyIntervalMin
+	keyIntervalSec
+	keyIntervalNsec
+	keyIntervalAdjust
+)
+
+// Interval is Tarantool datetime interval (MP_INTERVAL).
+// Pass *Interval in tuples and keys, decoded values are *Interval as well.
+// It is available since Tarantool >= 2.10.0
+type Interval struct {
+	Year   int64
+	Month  int64
+	Week   int64
+	Day    int64
+	Hour   int64
+	Min    int64
+	Sec    int64
+	Nsec   int64
+	Adjust IntervalAdjust
+}
+
+var _ msgp.Extension = (*Interval)(nil)
+
+func (iv *Interval) fields() []int64 {
+	return []int64{iv.Year, iv.Month, iv.Week, iv.Day, iv.Hour, iv.Min, iv.Sec, iv.Nsec, int64(iv.Adjust)}
+}
+
+// ExtensionType implements msgp.Extension
+func (iv *Interval) ExtensionType() int8 {
+	return ExtInterval
+}
+
+// Len implements msgp.Extension
+func (iv *Interval) Len() int {
+	return len(iv.appendBinary(nil))
+}
+
+// MarshalBinaryTo implements msgp.Extension
+func (iv *Interval) MarshalBinaryTo(b []byte) error {
+	copy(b, iv.appendBinary(nil))
+	return nil
+}
+
+// appendBinary appends the number of non-zero fields followed by their keys and values.
+func (iv *Interval) appendBinary(o []byte) []byte {
+	fields := iv.fields()
+
+	n := uint8(0)
+	for _, v := range fields {
+		if v != 0 {
+			n++
+		}
+	}
+
+	o = msgp.AppendUint8(o, n)
+	for k, v := range fields {
+		if v != 0 {
+			o = msgp.AppendUint8(o, uint8(k))
+			o = msgp.AppendInt64(o, v)
+		}
+	}
+	return o
+}
+
+// UnmarshalBinary implements msgp.Extension
+func (iv *Interval) UnmarshalBinary(data []byte) error {
+	var n, k uint8
+	var v int64
+	var err error
+
+	*iv = Interval{}
+
+	buf := data
+	if n, buf, err = msgp.ReadUint8Bytes(buf); err != nil {
+		return err
+	}
+
+	for ; n > 0; n-- {
+		if k, buf, err = msgp.ReadUint8Bytes(buf); err != nil {
+			return err
+		}
+		if v, buf, err = msgp.ReadInt64Bytes(buf); err != nil {
+			return err
+		}
+
+		switch k {
+		case keyIntervalYear:
+			iv.Year = v
+		case keyIntervalMonth:
+			iv.Month = v
+		case keyIntervalWeek:
+			iv.Week = v
+		case keyIntervalDay:
+			iv.Day = v
+		case keyIntervalHour:
+			iv.Hour = v
+		case keyIntervalMin:
+			iv.Min = v
+		case keyIntervalSec:
+			iv.Sec = v
+		case keyIntervalNsec:
+			iv.Nsec = v
+		case keyIntervalAdjust:
+			iv.Adjust = IntervalAdjust(v)
+		default:
+			return ErrBadInterval
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package tarantool
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ErrBadDecimal is returned by ParseDecimal and by decoding of malformed MP_DECIMAL.
+var ErrBadDecimal = errors.New("invalid decimal")
+
+// Decimal is Tarantool decimal (MP_DECIMAL), its value is Unscaled * 10^-Scale.
+// Pass *Decimal in tuples and keys, decoded values are *Decimal as well.
+// It is available since Tarantool >= 2.2.1
+type Decimal struct {
+	Unscaled *big.Int // nil means zero
+	Scale    int32
+}
+
+var _ msgp.Extension = (*Decimal)(nil)
+
+// NewDecimal returns unscaled * 10^-scale, e.g. NewDecimal(12345, 2) is 123.45
+func NewDecimal(unscaled int64, scale int32) *Decimal {
+	return &Decimal{Unscaled: big.NewInt(unscaled), Scale: scale}
+}
+
+// ParseDecimal parses the number in decimal notation with an optional exponent, e.g. "-123.45" or "1.5e-3".
+func ParseDecimal(s string) (*Decimal, error) {
+	var exp int64
+	var err error
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return nil, ErrBadDecimal
+		}
+		s = s[:i]
+	}
+
+	scale := int64(0)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = int64(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	if s == "" || s == "-" || s == "+" || strings.ContainsAny(s[1:], "+-") {
+		return nil, ErrBadDecimal
+	}
+
+	unscaled, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, ErrBadDecimal
+	}
+	return &Decimal{Unscaled: unscaled, Scale: int32(scale - exp)}, nil
+}
+
+func (d *Decimal) unscaled() *big.Int {
+	if d.Unscaled == nil {
+		return new(big.Int)
+	}
+	return d.Unscaled
+}
+
+// Rat returns the exact value of the decimal.
+func (d *Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.unscaled())
+	scale := big.NewInt(10)
+	if d.Scale >= 0 {
+		scale.Exp(scale, big.NewInt(int64(d.Scale)), nil)
+		return r.Quo(r, new(big.Rat).SetInt(scale))
+	}
+	scale.Exp(scale, big.NewInt(int64(-d.Scale)), nil)
+	return r.Mul(r, new(big.Rat).SetInt(scale))
+}
+
+// Float64 returns the nearest float64 value.
+func (d *Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func (d *Decimal) String() string {
+	u := d.unscaled()
+	digits := new(big.Int).Abs(u).String()
+
+	var sb strings.Builder
+	if u.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	switch scale := int(d.Scale); {
+	case scale <= 0:
+		sb.WriteString(digits)
+		if u.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", -scale))
+		}
+	case scale < len(digits):
+		sb.WriteString(digits[:len(digits)-scale])
+		sb.WriteByte('.')
+		sb.WriteString(digits[len(digits)-scale:])
+	default:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", scale-len(digits)))
+		sb.WriteString(digits)
+	}
+	return sb.String()
+}
+
+// ExtensionType implements msgp.Extension
+func (d *Decimal) ExtensionType() int8 {
+	return ExtDecimal
+}
+
+// Len implements msgp.Extension
+func (d *Decimal) Len() int {
+	return len(d.appendBinary(nil))
+}
+
+// MarshalBinaryTo implements msgp.Extension
+func (d *Decimal) MarshalBinaryTo(b []byte) error {
+	copy(b, d.appendBinary(nil))
+	return nil
+}
+
+// appendBinary appends the scale followed by the packed BCD digits with the sign in the last nibble.
+func (d *Decimal) appendBinary(o []byte) []byte {
+	u := d.unscaled()
+
+	if d.Scale < 0 {
+		o = msgp.AppendInt32(o, d.Scale)
+	} else {
+		o = msgp.AppendUint32(o, uint32(d.Scale))
+	}
+
+	digits := new(big.Int).Abs(u).String()
+	sign := byte(0x0c)
+	if u.Sign() < 0 {
+		sign = 0x0d
+	}
+
+	// digits and the sign nibble are packed into whole bytes
+	if len(digits)%2 == 0 {
+		digits = "0" + digits
+	}
+	for i := 0; i < len(digits)-1; i += 2 {
+		o = append(o, (digits[i]-'0')<<4|(digits[i+1]-'0'))
+	}
+	return append(o, (digits[len(digits)-1]-'0')<<4|sign)
+}
+
+// UnmarshalBinary implements msgp.Extension
+func (d *Decimal) UnmarshalBinary(data []byte) error {
+	var scale int64
+	var err error
+
+	buf := data
+	if scale, buf, err = msgp.ReadInt64Bytes(buf); err != nil {
+		return err
+	}
+	if len(buf) == 0 {
+		return ErrBadDecimal
+	}
+
+	digits := make([]byte, 0, len(buf)*2)
+	for i, b := range buf {
+		hi, lo := b>>4, b&0x0f
+		if hi > 9 {
+			return ErrBadDecimal
+		}
+		digits = append(digits, '0'+hi)
+		if i == len(buf)-1 {
+			break
+		}
+		if lo > 9 {
+			return ErrBadDecimal
+		}
+		digits = append(digits, '0'+lo)
+	}
+
+	unscaled, ok := new(big.Int).SetString(string(digits), 10)
+	if !ok {
+		return ErrBadDecimal
+	}
+
+	switch buf[len(buf)-1] & 0x0f {
+	case 0x0b, 0x0d:
+		unscaled.Neg(unscaled)
+	case 0x0a, 0x0c, 0x0e, 0x0f:
+	default:
+		return ErrBadDecimal
+	}
+
+	d.Unscaled = unscaled
+	d.Scale = int32(scale)
+	return nil
+}
@@ -12,6 +12,11 @@ var (
 	DefaultConnectTimeout = time.Second
 	DefaultQueryTimeout   = time.Second
 
+	DefaultReconnectDelay    = 100 * time.Millisecond
+	DefaultMaxReconnectDelay = 10 * time.Second
+
+	DefaultPoolCheckInterval = time.Second
+
 	DefaultReaderBufSize = 16 * 1024
 	DefaultWriterBufSize = 4 * 1024
 
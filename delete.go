@@ -83,7 +83,7 @@ func (q *Delete) UnmarshalMsg(data []byte) (buf []byte, err error) {
 				return
 			}
 		case KeyKey:
-			t, buf, err = msgp.ReadIntfBytes(buf)
+			t, buf, err = readIntfBytes(buf)
 			if q.KeyTuple = t.([]interface{}); q.KeyTuple == nil {
 				return buf, errors.New("interface type is not []interface{}")
 			}
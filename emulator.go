@@ -0,0 +1,550 @@
+package tarantool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// EmulatorIndex describes an index of the space created by Emulator.
+type EmulatorIndex struct {
+	Name string
+	// Type is TreeIndex, HashIndex or BitsetIndex, it is TreeIndex if empty.
+	// Hash indexes are unique, bitset indexes are not unique and have one part.
+	Type   string
+	Unique bool
+	// Parts are the numbers of the indexed fields starting from 0.
+	Parts []uint
+}
+
+// EmulatorFunc is a Go function available to Call and Call17 requests,
+// it gets the arguments of the call and returns the values to send back.
+// QueryError returned by the function is passed with its code, other errors get ErrProcLua.
+type EmulatorFunc func(ctx context.Context, args []interface{}) ([]interface{}, error)
+
+// Emulator is an in-memory Tarantool for tests, it serves Select, Insert, Replace,
+// Update, Upsert and Delete requests to its spaces and calls of registered functions.
+// It also serves _vspace and _vindex, so Connection gets the schema as usual.
+// Any credentials are accepted, other requests get ErrUnsupported.
+//
+//	emu := NewEmulator()
+//	emu.CreateSpace(512, "users", nil, EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{0}})
+//	addr, err := emu.Start("127.0.0.1:0")
+//	...
+//	defer emu.Close()
+//	conn, err := Connect(addr, nil)
+type Emulator struct {
+	sync.Mutex
+	spaces map[uint]*emuSpace
+	names  map[string]*emuSpace
+	mux    *ServeMux
+	server *Server
+}
+
+type emuSpace struct {
+	id      uint
+	name    string
+	format  []SpaceField
+	indexes []*emuIndex
+	system  bool
+}
+
+var (
+	vspaceFormat = []SpaceField{
+		{Name: "id", Type: "unsigned"},
+		{Name: "owner", Type: "unsigned"},
+		{Name: "name", Type: "string"},
+		{Name: "engine", Type: "string"},
+		{Name: "field_count", Type: "unsigned"},
+		{Name: "flags", Type: "map"},
+		{Name: "format", Type: "array"},
+	}
+	vindexFormat = []SpaceField{
+		{Name: "id", Type: "unsigned"},
+		{Name: "iid", Type: "unsigned"},
+		{Name: "name", Type: "string"},
+		{Name: "type", Type: "string"},
+		{Name: "opts", Type: "map"},
+		{Name: "parts", Type: "array"},
+	}
+)
+
+func NewEmulator() *Emulator {
+	e := &Emulator{
+		spaces: make(map[uint]*emuSpace),
+		names:  make(map[string]*emuSpace),
+		mux:    NewServeMux(),
+	}
+
+	e.mux.Handle(AuthCommand, func(context.Context, Query) *Result { return &Result{} })
+	e.mux.Handle(SelectCommand, e.handleSelect)
+	e.mux.Handle(InsertCommand, e.handleInsert)
+	e.mux.Handle(ReplaceCommand, e.handleReplace)
+	e.mux.Handle(UpdateCommand, e.handleUpdate)
+	e.mux.Handle(UpsertCommand, e.handleUpsert)
+	e.mux.Handle(DeleteCommand, e.handleDelete)
+
+	e.createSpace(ViewSpace, "_vspace", vspaceFormat, []EmulatorIndex{
+		{Name: "primary", Unique: true, Parts: []uint{0}},
+		{Name: "owner", Parts: []uint{1}},
+		{Name: "name", Unique: true, Parts: []uint{2}},
+	})
+	e.createSpace(ViewIndex, "_vindex", vindexFormat, []EmulatorIndex{
+		{Name: "primary", Unique: true, Parts: []uint{0, 1}},
+		{Name: "name", Unique: true, Parts: []uint{0, 2}},
+	})
+	for _, id := range []uint{ViewSpace, ViewIndex} {
+		e.spaces[id].system = true
+		e.describeSpace(e.spaces[id])
+	}
+
+	e.server = NewServer(randomUUID(), e.Serve, nil)
+
+	return e
+}
+
+// CreateSpace creates the space with the indexes, the first index is the primary one and must be unique.
+// The format is optional, it lets update operators refer to the fields by name.
+func (e *Emulator) CreateSpace(id uint, name string, format []SpaceField, indexes ...EmulatorIndex) error {
+	e.Lock()
+	defer e.Unlock()
+
+	if err := e.createSpace(id, name, format, indexes); err != nil {
+		return err
+	}
+	e.describeSpace(e.spaces[id])
+	return nil
+}
+
+func (e *Emulator) createSpace(id uint, name string, format []SpaceField, indexes []EmulatorIndex) error {
+	if _, exists := e.spaces[id]; exists {
+		return NewQueryError(ErrSpaceExists, fmt.Sprintf("Space '%d' already exists", id))
+	}
+	if _, exists := e.names[name]; exists {
+		return NewQueryError(ErrSpaceExists, fmt.Sprintf("Space '%s' already exists", name))
+	}
+	if len(indexes) == 0 || !indexes[0].Unique {
+		return NewQueryError(ErrIllegalParams, fmt.Sprintf("Illegal parameters, space '%s' has no unique primary index", name))
+	}
+
+	s := &emuSpace{id: id, name: name, format: format}
+	for i, opts := range indexes {
+		idx := &emuIndex{
+			id:     uint(i),
+			name:   opts.Name,
+			kind:   strings.ToUpper(opts.Type),
+			unique: opts.Unique,
+			parts:  opts.Parts,
+		}
+		if idx.kind == "" {
+			idx.kind = TreeIndex
+		}
+
+		var valid bool
+		switch idx.kind {
+		case TreeIndex:
+			valid = len(idx.parts) > 0
+		case HashIndex:
+			valid = len(idx.parts) > 0 && idx.unique
+		case BitsetIndex:
+			valid = len(idx.parts) == 1 && !idx.unique && i > 0
+		}
+		if !valid {
+			return NewQueryError(ErrIndexType, fmt.Sprintf("Unsupported index type supplied for index '%s' in space '%s'", idx.name, name))
+		}
+
+		idx.primary = indexes[0].Parts
+		s.indexes = append(s.indexes, idx)
+	}
+
+	e.spaces[id] = s
+	e.names[name] = s
+	return nil
+}
+
+// describeSpace adds the space to _vspace and _vindex.
+func (e *Emulator) describeSpace(s *emuSpace) {
+	format := make([]interface{}, len(s.format))
+	for i, f := range s.format {
+		format[i] = map[string]interface{}{"name": f.Name, "type": f.Type, "is_nullable": f.IsNullable}
+	}
+	e.spaces[ViewSpace].write([]interface{}{
+		uint64(s.id), uint64(1), s.name, "memtx", uint64(0), map[string]interface{}{}, format,
+	}, nil)
+
+	for _, idx := range s.indexes {
+		fieldType := "scalar"
+		if idx.kind == BitsetIndex {
+			fieldType = "unsigned"
+		}
+		parts := make([]interface{}, len(idx.parts))
+		for i, part := range idx.parts {
+			parts[i] = []interface{}{uint64(part), fieldType}
+		}
+		e.spaces[ViewIndex].write([]interface{}{
+			uint64(s.id), uint64(idx.id), idx.name, strings.ToLower(idx.kind),
+			map[string]interface{}{"unique": idx.unique}, parts,
+		}, nil)
+	}
+}
+
+// RegisterFunc makes the function available to Call and Call17 requests.
+func (e *Emulator) RegisterFunc(name string, fn EmulatorFunc) {
+	e.mux.HandleFunc(name, func(ctx context.Context, q Query) *Result {
+		var args []interface{}
+		switch q := q.(type) {
+		case *Call:
+			args = q.Tuple
+		case *Call17:
+			args = q.Tuple
+		}
+
+		values, err := fn(ctx, args)
+		if err != nil {
+			return errorResult(err, ErrProcLua)
+		}
+
+		if _, ok := q.(*Call17); ok {
+			if values == nil {
+				values = []interface{}{}
+			}
+			return &Result{RawData: values}
+		}
+
+		// Call returns the values as tuples
+		tuples := make([][]interface{}, len(values))
+		for i, value := range values {
+			if tuple, ok := value.([]interface{}); ok {
+				tuples[i] = tuple
+			} else {
+				tuples[i] = []interface{}{value}
+			}
+		}
+		return &Result{Data: tuples}
+	})
+}
+
+// Serve implements QueryHandler, it lets the emulator be served by IprotoServer with custom options.
+func (e *Emulator) Serve(ctx context.Context, q Query) *Result {
+	return e.mux.Serve(ctx, q)
+}
+
+// Start serves the emulator on the TCP address in background,
+// it returns the address being listened, e.g. for "127.0.0.1:0".
+func (e *Emulator) Start(address string) (string, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+	go e.server.Serve(l)
+	return l.Addr().String(), nil
+}
+
+// Close stops serving the emulator and closes the connections.
+func (e *Emulator) Close() error {
+	return e.server.Close()
+}
+
+func emuResult(tuple []interface{}, err error) *Result {
+	if err != nil {
+		return errorResult(err, ErrIllegalParams)
+	}
+	if tuple == nil {
+		return &Result{}
+	}
+	return &Result{Data: [][]interface{}{tuple}}
+}
+
+func (e *Emulator) space(space interface{}, write bool) (*emuSpace, error) {
+	var s *emuSpace
+	if name, ok := space.(string); ok {
+		s = e.names[name]
+	} else if id, err := numberToUint64(space); err == nil {
+		s = e.spaces[uint(id)]
+	}
+
+	if s == nil {
+		return nil, NewQueryError(ErrNoSuchSpace, fmt.Sprintf("Space '%v' does not exist", space))
+	}
+	if write && s.system {
+		return nil, NewQueryError(ErrAccessDenied, fmt.Sprintf("Write access to space '%s' is denied for user '%s'", s.name, GuestUser))
+	}
+	return s, nil
+}
+
+func (e *Emulator) index(space interface{}, index interface{}, write bool) (*emuSpace, *emuIndex, error) {
+	s, err := e.space(space, write)
+	if err != nil {
+		return nil, nil, err
+	}
+	if index == nil {
+		return s, s.indexes[0], nil
+	}
+
+	if name, ok := index.(string); ok {
+		for _, idx := range s.indexes {
+			if idx.name == name {
+				return s, idx, nil
+			}
+		}
+		return nil, nil, NewQueryError(ErrNoSuchIndexName, fmt.Sprintf("No index '%s' is defined in space '%s'", name, s.name))
+	}
+
+	id, err := numberToUint64(index)
+	if err != nil || id >= uint64(len(s.indexes)) {
+		return nil, nil, NewQueryError(ErrNoSuchIndex, fmt.Sprintf("No index #%v is defined in space '%s'", index, s.name))
+	}
+	return s, s.indexes[id], nil
+}
+
+func queryKey(key interface{}, keyTuple []interface{}) []interface{} {
+	if key != nil {
+		return []interface{}{key}
+	}
+	return keyTuple
+}
+
+func (e *Emulator) handleSelect(_ context.Context, query Query) *Result {
+	q := query.(*Select)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, idx, err := e.index(q.Space, q.Index, false)
+	if err != nil {
+		return errorResult(err, ErrNoSuchSpace)
+	}
+
+	key := queryKey(q.Key, q.KeyTuple)
+	if len(key) > len(idx.parts) {
+		return errorResult(NewQueryError(ErrKeyPartCount, fmt.Sprintf("Invalid key part count (expected [0..%d], got %d)", len(idx.parts), len(key))), ErrIllegalParams)
+	}
+	if !idx.supports(q.Iterator) {
+		return errorResult(NewQueryError(ErrUnsupportedIndexFeature, fmt.Sprintf("Index '%s' (%s) of space '%s' (memtx) does not support requested iterator type", idx.name, idx.kind, s.name)), ErrIllegalParams)
+	}
+	if idx.kind == HashIndex && q.Iterator == IterEq && len(key) > 0 && len(key) != len(idx.parts) {
+		return errorResult(NewQueryError(ErrPartialKey, fmt.Sprintf("HASH index  does not support selects via a partial key (expected %d parts, got %d). Please Consider changing index type to TREE.", len(idx.parts), len(key))), ErrIllegalParams)
+	}
+
+	tuples := idx.iterate(q.Iterator, key)
+	if int(q.Offset) >= len(tuples) {
+		return &Result{}
+	}
+	tuples = tuples[q.Offset:]
+	if q.Limit > 0 && int(q.Limit) < len(tuples) {
+		tuples = tuples[:q.Limit]
+	}
+	return &Result{Data: tuples}
+}
+
+func (e *Emulator) handleInsert(_ context.Context, query Query) *Result {
+	q := query.(*Insert)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, err := e.space(q.Space, true)
+	if err == nil {
+		err = s.insert(q.Tuple)
+	}
+	return emuResult(q.Tuple, err)
+}
+
+func (e *Emulator) handleReplace(_ context.Context, query Query) *Result {
+	q := query.(*Replace)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, err := e.space(q.Space, true)
+	if err == nil {
+		err = s.replace(q.Tuple)
+	}
+	return emuResult(q.Tuple, err)
+}
+
+func (e *Emulator) handleUpdate(_ context.Context, query Query) *Result {
+	q := query.(*Update)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, idx, err := e.index(q.Space, q.Index, true)
+	if err != nil {
+		return errorResult(err, ErrIllegalParams)
+	}
+	return emuResult(s.update(idx, queryKey(q.Key, q.KeyTuple), q.Set))
+}
+
+func (e *Emulator) handleUpsert(_ context.Context, query Query) *Result {
+	q := query.(*Upsert)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, err := e.space(q.Space, true)
+	if err == nil {
+		err = s.upsert(q.Tuple, q.Set)
+	}
+	return emuResult(nil, err)
+}
+
+func (e *Emulator) handleDelete(_ context.Context, query Query) *Result {
+	q := query.(*Delete)
+
+	e.Lock()
+	defer e.Unlock()
+
+	s, idx, err := e.index(q.Space, q.Index, true)
+	if err != nil {
+		return errorResult(err, ErrIllegalParams)
+	}
+	return emuResult(s.delete(idx, queryKey(q.Key, q.KeyTuple)))
+}
+
+func (s *emuSpace) primary() *emuIndex {
+	return s.indexes[0]
+}
+
+func (s *emuSpace) fieldNo(name string) (uint, bool) {
+	for i, f := range s.format {
+		if f.Name == name {
+			return uint(i), true
+		}
+	}
+	return 0, false
+}
+
+// validate checks that the tuple has all the indexed fields.
+func (s *emuSpace) validate(tuple []interface{}) error {
+	for _, idx := range s.indexes {
+		for _, part := range idx.parts {
+			if int(part) >= len(tuple) {
+				return NewQueryError(ErrNoSuchField, fmt.Sprintf("Tuple field %d required by index '%s' is missing", part, idx.name))
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnique checks that the tuple replacing the old one, if any, does not duplicate other tuples.
+func (s *emuSpace) checkUnique(tuple, old []interface{}) error {
+	for _, idx := range s.indexes {
+		if !idx.unique {
+			continue
+		}
+		found := idx.get(idx.key(tuple))
+		if found != nil && (old == nil || s.primary().compare(found, old) != 0) {
+			return NewQueryError(ErrTupleFound, fmt.Sprintf("Duplicate key exists in unique index '%s' in space '%s'", idx.name, s.name))
+		}
+	}
+	return nil
+}
+
+// write replaces the old tuple, if any, with the new one in all the indexes.
+func (s *emuSpace) write(tuple, old []interface{}) {
+	for _, idx := range s.indexes {
+		if old != nil {
+			idx.remove(old)
+		}
+		if tuple != nil {
+			idx.insert(tuple)
+		}
+	}
+}
+
+// get returns the tuple by the full key of the unique index.
+func (s *emuSpace) get(idx *emuIndex, key []interface{}) ([]interface{}, error) {
+	if !idx.unique {
+		return nil, NewQueryError(ErrMoreThanOneTuple, "Get() doesn't support partial keys and non-unique indexes")
+	}
+	if len(key) != len(idx.parts) {
+		return nil, NewQueryError(ErrExactMatch, fmt.Sprintf("Invalid key part count in an exact match (expected %d, got %d)", len(idx.parts), len(key)))
+	}
+	return idx.get(key), nil
+}
+
+func (s *emuSpace) insert(tuple []interface{}) error {
+	if err := s.validate(tuple); err != nil {
+		return err
+	}
+	if err := s.checkUnique(tuple, nil); err != nil {
+		return err
+	}
+	s.write(tuple, nil)
+	return nil
+}
+
+func (s *emuSpace) replace(tuple []interface{}) error {
+	if err := s.validate(tuple); err != nil {
+		return err
+	}
+	old := s.primary().get(s.primary().key(tuple))
+	if err := s.checkUnique(tuple, old); err != nil {
+		return err
+	}
+	s.write(tuple, old)
+	return nil
+}
+
+func (s *emuSpace) delete(idx *emuIndex, key []interface{}) ([]interface{}, error) {
+	old, err := s.get(idx, key)
+	if err != nil || old == nil {
+		return nil, err
+	}
+	s.write(nil, old)
+	return old, nil
+}
+
+func (s *emuSpace) update(idx *emuIndex, key []interface{}, ops []Operator) ([]interface{}, error) {
+	old, err := s.get(idx, key)
+	if err != nil || old == nil {
+		return nil, err
+	}
+
+	tuple, err := s.applyOperators(old, ops)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.replaceUpdated(tuple, old); err != nil {
+		return nil, err
+	}
+	return tuple, nil
+}
+
+// upsert inserts the tuple or updates the existing one, the operators failed are skipped as in Tarantool.
+func (s *emuSpace) upsert(tuple []interface{}, ops []Operator) error {
+	if err := s.validate(tuple); err != nil {
+		return err
+	}
+
+	old := s.primary().get(s.primary().key(tuple))
+	if old == nil {
+		return s.insert(tuple)
+	}
+
+	updated := append([]interface{}(nil), old...)
+	for _, op := range ops {
+		if applied, err := s.applyOperator(append([]interface{}(nil), updated...), op); err == nil {
+			updated = applied
+		}
+	}
+	return s.replaceUpdated(updated, old)
+}
+
+func (s *emuSpace) replaceUpdated(tuple, old []interface{}) error {
+	if err := s.validate(tuple); err != nil {
+		return err
+	}
+	pk := s.primary()
+	if pk.compareKey(tuple, pk.key(old)) != 0 {
+		return NewQueryError(ErrCantUpdatePrimaryKey, fmt.Sprintf("Attempt to modify a tuple field which is part of index '%s' in space '%s'", pk.name, s.name))
+	}
+	if err := s.checkUnique(tuple, old); err != nil {
+		return err
+	}
+	s.write(tuple, old)
+	return nil
+}
@@ -0,0 +1,328 @@
+package tarantool
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Index types of Emulator.
+const (
+	TreeIndex   = "TREE"
+	HashIndex   = "HASH"
+	BitsetIndex = "BITSET"
+)
+
+// emuIndex keeps the tuples of the space sorted by the key,
+// the tuples with equal keys of a non-unique index are sorted by the primary key.
+type emuIndex struct {
+	id      uint
+	name    string
+	kind    string
+	unique  bool
+	parts   []uint
+	primary []uint
+	tuples  [][]interface{}
+}
+
+// supports reports whether the iterator is available for the index type as in Tarantool.
+func (idx *emuIndex) supports(iterator uint8) bool {
+	switch idx.kind {
+	case HashIndex:
+		return iterator == IterEq || iterator == IterAll || iterator == IterGt
+	case BitsetIndex:
+		return iterator == IterEq || iterator == IterAll ||
+			iterator == IterBitsAllSet || iterator == IterBitsAnySet || iterator == IterBitsAllNotSet
+	}
+	return iterator <= IterGt
+}
+
+func (idx *emuIndex) key(tuple []interface{}) []interface{} {
+	key := make([]interface{}, len(idx.parts))
+	for i, part := range idx.parts {
+		key[i] = tupleField(tuple, part)
+	}
+	return key
+}
+
+// compareKey compares the tuple with the key which may be a prefix of the index key.
+func (idx *emuIndex) compareKey(tuple []interface{}, key []interface{}) int {
+	for i := range key {
+		if c := compareValues(tupleField(tuple, idx.parts[i]), key[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (idx *emuIndex) compare(a, b []interface{}) int {
+	if c := idx.compareKey(a, idx.key(b)); c != 0 || idx.unique {
+		return c
+	}
+	for _, part := range idx.primary {
+		if c := compareValues(tupleField(a, part), tupleField(b, part)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (idx *emuIndex) insert(tuple []interface{}) {
+	i := sort.Search(len(idx.tuples), func(i int) bool {
+		return idx.compare(idx.tuples[i], tuple) >= 0
+	})
+	idx.tuples = append(idx.tuples, nil)
+	copy(idx.tuples[i+1:], idx.tuples[i:])
+	idx.tuples[i] = tuple
+}
+
+func (idx *emuIndex) remove(tuple []interface{}) {
+	i := sort.Search(len(idx.tuples), func(i int) bool {
+		return idx.compare(idx.tuples[i], tuple) >= 0
+	})
+	if i < len(idx.tuples) && idx.compare(idx.tuples[i], tuple) == 0 {
+		idx.tuples = append(idx.tuples[:i], idx.tuples[i+1:]...)
+	}
+}
+
+// get returns the tuple matching the full key of the unique index or nil.
+func (idx *emuIndex) get(key []interface{}) []interface{} {
+	i := sort.Search(len(idx.tuples), func(i int) bool {
+		return idx.compareKey(idx.tuples[i], key) >= 0
+	})
+	if i < len(idx.tuples) && idx.compareKey(idx.tuples[i], key) == 0 {
+		return idx.tuples[i]
+	}
+	return nil
+}
+
+// iterate returns the tuples selected by the iterator in its order.
+func (idx *emuIndex) iterate(iterator uint8, key []interface{}) [][]interface{} {
+	switch iterator {
+	case IterBitsAllSet, IterBitsAnySet, IterBitsAllNotSet:
+		return idx.iterateBits(iterator, key)
+	}
+
+	if len(key) == 0 {
+		switch iterator {
+		case IterReq, IterLt, IterLe:
+			return reversedTuples(idx.tuples)
+		}
+		return append([][]interface{}(nil), idx.tuples...)
+	}
+
+	lo := sort.Search(len(idx.tuples), func(i int) bool {
+		return idx.compareKey(idx.tuples[i], key) >= 0
+	})
+	hi := sort.Search(len(idx.tuples), func(i int) bool {
+		return idx.compareKey(idx.tuples[i], key) > 0
+	})
+
+	switch iterator {
+	case IterEq:
+		return append([][]interface{}(nil), idx.tuples[lo:hi]...)
+	case IterReq:
+		return reversedTuples(idx.tuples[lo:hi])
+	case IterLt:
+		return reversedTuples(idx.tuples[:lo])
+	case IterLe:
+		return reversedTuples(idx.tuples[:hi])
+	case IterGe:
+		return append([][]interface{}(nil), idx.tuples[lo:]...)
+	case IterGt:
+		return append([][]interface{}(nil), idx.tuples[hi:]...)
+	}
+	return append([][]interface{}(nil), idx.tuples...)
+}
+
+// iterateBits matches the first part of the index against the bit mask as BITSET indexes do.
+func (idx *emuIndex) iterateBits(iterator uint8, key []interface{}) [][]interface{} {
+	var mask uint64
+	if len(key) > 0 {
+		mask, _ = numberToUint64(key[0])
+	}
+
+	var tuples [][]interface{}
+	for _, tuple := range idx.tuples {
+		value, err := numberToUint64(tupleField(tuple, idx.parts[0]))
+		if err != nil {
+			continue
+		}
+
+		var match bool
+		switch iterator {
+		case IterBitsAllSet:
+			match = value&mask == mask
+		case IterBitsAnySet:
+			match = value&mask != 0
+		case IterBitsAllNotSet:
+			match = value&mask == 0
+		}
+		if match {
+			tuples = append(tuples, tuple)
+		}
+	}
+	return tuples
+}
+
+func reversedTuples(tuples [][]interface{}) [][]interface{} {
+	reversed := make([][]interface{}, len(tuples))
+	for i, tuple := range tuples {
+		reversed[len(tuples)-1-i] = tuple
+	}
+	return reversed
+}
+
+func tupleField(tuple []interface{}, fieldNo uint) interface{} {
+	if int(fieldNo) < len(tuple) {
+		return tuple[fieldNo]
+	}
+	return nil
+}
+
+// value classes are ordered as in scalar indexes of Tarantool
+const (
+	valueNil = iota
+	valueBool
+	valueNumber
+	valueString
+	valueBinary
+	valueOther
+)
+
+func valueClass(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return valueNil
+	case bool:
+		return valueBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return valueNumber
+	case string:
+		return valueString
+	case []byte:
+		return valueBinary
+	}
+	return valueOther
+}
+
+func compareValues(a, b interface{}) int {
+	ca, cb := valueClass(a), valueClass(b)
+	if ca != cb {
+		if ca < cb {
+			return -1
+		}
+		return 1
+	}
+
+	switch ca {
+	case valueNil:
+		return 0
+	case valueBool:
+		if a.(bool) == b.(bool) {
+			return 0
+		} else if b.(bool) {
+			return -1
+		}
+		return 1
+	case valueNumber:
+		return compareNumbers(a, b)
+	case valueString:
+		return strings.Compare(a.(string), b.(string))
+	case valueBinary:
+		return bytes.Compare(a.([]byte), b.([]byte))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// emuNumber is a number of any Go type, unsigned values beyond int64 are kept in u.
+type emuNumber struct {
+	i       int64
+	u       uint64
+	f       float64
+	isUint  bool
+	isFloat bool
+}
+
+func toEmuNumber(v interface{}) (n emuNumber, ok bool) {
+	switch v := v.(type) {
+	case float32:
+		return emuNumber{f: float64(v), isFloat: true}, true
+	case float64:
+		return emuNumber{f: v, isFloat: true}, true
+	case uint:
+		return uintEmuNumber(uint64(v)), true
+	case uint64:
+		return uintEmuNumber(v), true
+	case int:
+		return emuNumber{i: int64(v)}, true
+	case int8:
+		return emuNumber{i: int64(v)}, true
+	case int16:
+		return emuNumber{i: int64(v)}, true
+	case int32:
+		return emuNumber{i: int64(v)}, true
+	case int64:
+		return emuNumber{i: v}, true
+	case uint8:
+		return emuNumber{i: int64(v)}, true
+	case uint16:
+		return emuNumber{i: int64(v)}, true
+	case uint32:
+		return emuNumber{i: int64(v)}, true
+	}
+	return n, false
+}
+
+func uintEmuNumber(v uint64) emuNumber {
+	if v > math.MaxInt64 {
+		return emuNumber{u: v, isUint: true}
+	}
+	return emuNumber{i: int64(v)}
+}
+
+func (n emuNumber) float() float64 {
+	switch {
+	case n.isFloat:
+		return n.f
+	case n.isUint:
+		return float64(n.u)
+	}
+	return float64(n.i)
+}
+
+func compareNumbers(a, b interface{}) int {
+	na, _ := toEmuNumber(a)
+	nb, _ := toEmuNumber(b)
+
+	switch {
+	case na.isFloat || nb.isFloat:
+		fa, fb := na.float(), nb.float()
+		if fa < fb {
+			return -1
+		} else if fa > fb {
+			return 1
+		}
+		return 0
+	case na.isUint && nb.isUint:
+		if na.u < nb.u {
+			return -1
+		} else if na.u > nb.u {
+			return 1
+		}
+		return 0
+	case na.isUint:
+		return 1
+	case nb.isUint:
+		return -1
+	}
+
+	if na.i < nb.i {
+		return -1
+	} else if na.i > nb.i {
+		return 1
+	}
+	return 0
+}
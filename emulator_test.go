@@ -0,0 +1,218 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEmulator(t *testing.T) (*Emulator, *Connection) {
+	emu := NewEmulator()
+	require.NoError(t, emu.CreateSpace(512, "users", []SpaceField{
+		{Name: "id", Type: "unsigned"},
+		{Name: "name", Type: "string"},
+		{Name: "age", Type: "unsigned"},
+		{Name: "flags", Type: "unsigned"},
+	},
+		EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{0}},
+		EmulatorIndex{Name: "name", Type: HashIndex, Unique: true, Parts: []uint{1}},
+		EmulatorIndex{Name: "age", Parts: []uint{2}},
+		EmulatorIndex{Name: "flags", Type: BitsetIndex, Parts: []uint{3}},
+	))
+
+	addr, err := emu.Start("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := Connect(addr, &Options{User: "tester", Password: "any"})
+	require.NoError(t, err)
+
+	for _, tuple := range [][]interface{}{
+		{uint64(1), "alice", uint64(30), uint64(1)},
+		{uint64(2), "bob", uint64(25), uint64(3)},
+		{uint64(3), "carol", uint64(30), uint64(4)},
+		{uint64(4), "dave", uint64(40), uint64(6)},
+	} {
+		res := conn.Exec(context.Background(), &Insert{Space: "users", Tuple: tuple})
+		require.NoError(t, res.Error)
+	}
+	return emu, conn
+}
+
+// tupleIDs returns the first fields of the tuples.
+func tupleIDs(data [][]interface{}) []int64 {
+	ids := []int64{}
+	for _, tuple := range data {
+		ids = append(ids, tuple[0].(int64))
+	}
+	return ids
+}
+
+func TestEmulatorSelect(t *testing.T) {
+	emu, conn := newTestEmulator(t)
+	defer emu.Close()
+	defer conn.Close()
+
+	fields, ok := conn.GetPrimaryKeyFields("users")
+	require.True(t, ok)
+	assert.Equal(t, []int{0}, fields)
+
+	tests := []struct {
+		name     string
+		query    *Select
+		expected []int64
+	}{
+		{"eq", &Select{Space: "users", Key: uint64(2)}, []int64{2}},
+		{"eq missing", &Select{Space: "users", Key: uint64(5)}, []int64{}},
+		{"all", &Select{Space: "users", Iterator: IterAll}, []int64{1, 2, 3, 4}},
+		{"req", &Select{Space: "users", Index: "age", Key: uint64(30), Iterator: IterReq}, []int64{3, 1}},
+		{"lt", &Select{Space: "users", Key: uint64(3), Iterator: IterLt}, []int64{2, 1}},
+		{"le", &Select{Space: "users", Key: uint64(3), Iterator: IterLe}, []int64{3, 2, 1}},
+		{"ge", &Select{Space: "users", Key: uint64(3), Iterator: IterGe}, []int64{3, 4}},
+		{"gt", &Select{Space: "users", Key: uint64(3), Iterator: IterGt}, []int64{4}},
+		{"lt empty key", &Select{Space: "users", Iterator: IterLt}, []int64{4, 3, 2, 1}},
+		{"non-unique eq", &Select{Space: "users", Index: "age", Key: uint64(30)}, []int64{1, 3}},
+		{"non-unique ge", &Select{Space: "users", Index: "age", Key: uint64(26), Iterator: IterGe}, []int64{1, 3, 4}},
+		{"offset and limit", &Select{Space: "users", Iterator: IterAll, Offset: 1, Limit: 2}, []int64{2, 3}},
+		{"hash eq", &Select{Space: "users", Index: "name", Key: "carol"}, []int64{3}},
+		{"hash gt", &Select{Space: "users", Index: "name", Key: "bob", Iterator: IterGt}, []int64{3, 4}},
+		{"bits all set", &Select{Space: "users", Index: "flags", Key: uint64(2), Iterator: IterBitsAllSet}, []int64{2, 4}},
+		{"bits any set", &Select{Space: "users", Index: "flags", Key: uint64(5), Iterator: IterBitsAnySet}, []int64{1, 2, 3, 4}},
+		{"bits all not set", &Select{Space: "users", Index: "flags", Key: uint64(2), Iterator: IterBitsAllNotSet}, []int64{1, 3}},
+	}
+	for _, tc := range tests {
+		res := conn.Exec(context.Background(), tc.query)
+		if assert.NoError(t, res.Error, tc.name) {
+			assert.Equal(t, tc.expected, tupleIDs(res.Data), tc.name)
+		}
+	}
+
+	res := conn.Exec(context.Background(), &Select{Space: "users", Index: "name", Key: "bob", Iterator: IterLt})
+	assert.Equal(t, ErrUnsupportedIndexFeature, res.ErrorCode)
+
+	res = conn.Exec(context.Background(), &Select{Space: 600, Key: uint64(1)})
+	assert.Equal(t, ErrNoSuchSpace, res.ErrorCode)
+
+	res = conn.Exec(context.Background(), &Select{Space: "users", Index: 9, Key: uint64(1)})
+	assert.Equal(t, ErrNoSuchIndex, res.ErrorCode)
+}
+
+func TestEmulatorModify(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	emu, conn := newTestEmulator(t)
+	defer emu.Close()
+	defer conn.Close()
+
+	exec := func(q Query) *Result {
+		return conn.Exec(context.Background(), q)
+	}
+
+	res := exec(&Insert{Space: "users", Tuple: []interface{}{uint64(1), "eve", uint64(20), uint64(0)}})
+	assert.Equal(ErrTupleFound, res.ErrorCode)
+	res = exec(&Insert{Space: "users", Tuple: []interface{}{uint64(5), "bob", uint64(20), uint64(0)}})
+	assert.Equal(ErrTupleFound, res.ErrorCode)
+	res = exec(&Insert{Space: "users", Tuple: []interface{}{uint64(5)}})
+	assert.Equal(ErrNoSuchField, res.ErrorCode)
+
+	res = exec(&Replace{Space: "users", Tuple: []interface{}{uint64(1), "alice", uint64(31), uint64(1)}})
+	require.NoError(res.Error)
+	res = exec(&Select{Space: "users", Index: "age", Key: uint64(31)})
+	assert.Equal([]int64{1}, tupleIDs(res.Data))
+
+	res = exec(&Update{Space: "users", Key: uint64(2), Set: []Operator{
+		&OpAdd{FieldName: "age", Argument: 5},
+		&OpSub{Field: 3, Argument: 1},
+		&OpBitOR{Field: 3, Argument: 8},
+		&OpBitAND{Field: 3, Argument: 12},
+		&OpBitXOR{Field: 3, Argument: 1},
+		&OpSplice{Field: 1, Position: 1, Offset: 2, Argument: "rian"},
+		&OpInsert{Before: 4, Argument: "x"},
+		&OpAssign{Field: -1, Argument: "y"},
+		&OpInsert{Before: -1, Argument: "z"},
+		&OpDelete{From: 4, Count: 1},
+	}})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{int64(2), "brian", int64(30), int64(9), "z"}}, res.Data)
+
+	res = exec(&Update{Space: "users", Index: "name", Key: "brian", Set: []Operator{&OpAssign{Field: 0, Argument: uint64(9)}}})
+	assert.Equal(ErrCantUpdatePrimaryKey, res.ErrorCode)
+	res = exec(&Update{Space: "users", Key: uint64(2), Set: []Operator{&OpAssign{Field: 1, Argument: "carol"}}})
+	assert.Equal(ErrTupleFound, res.ErrorCode)
+	res = exec(&Update{Space: "users", Key: uint64(2), Set: []Operator{&OpAdd{Field: 1, Argument: 1}}})
+	assert.Equal(ErrArgType, res.ErrorCode)
+	res = exec(&Update{Space: "users", Index: "age", Key: uint64(30), Set: []Operator{&OpAdd{Field: 2, Argument: 1}}})
+	assert.Equal(ErrMoreThanOneTuple, res.ErrorCode)
+	res = exec(&Update{Space: "users", Key: uint64(9), Set: []Operator{&OpAdd{Field: 2, Argument: 1}}})
+	require.NoError(res.Error)
+	assert.Empty(res.Data)
+
+	res = exec(&Upsert{Space: "users", Tuple: []interface{}{uint64(5), "frank", uint64(50), uint64(0)}, Set: []Operator{&OpAdd{Field: 2, Argument: 1}}})
+	require.NoError(res.Error)
+	res = exec(&Upsert{Space: "users", Tuple: []interface{}{uint64(5), "frank", uint64(50), uint64(0)}, Set: []Operator{
+		&OpAdd{Field: 1, Argument: 1},
+		&OpAdd{Field: 2, Argument: 1},
+	}})
+	require.NoError(res.Error)
+	res = exec(&Select{Space: "users", Key: uint64(5)})
+	assert.Equal([][]interface{}{{int64(5), "frank", int64(51), int64(0)}}, res.Data)
+
+	res = exec(&Delete{Space: "users", Index: "name", Key: "frank"})
+	require.NoError(res.Error)
+	assert.Equal([]int64{5}, tupleIDs(res.Data))
+	res = exec(&Select{Space: "users", Iterator: IterAll})
+	assert.Equal([]int64{1, 2, 3, 4}, tupleIDs(res.Data))
+	res = exec(&Select{Space: "users", Index: "flags", Iterator: IterAll})
+	assert.Len(res.Data, 4)
+
+	res = exec(&Insert{Space: ViewSpace, Tuple: []interface{}{uint64(600), uint64(1), "hack"}})
+	assert.Equal(ErrAccessDenied, res.ErrorCode)
+	res = exec(&Eval{Expression: "return 1"})
+	assert.Equal(ErrUnsupported, res.ErrorCode)
+}
+
+func TestEmulatorCall(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	emu, conn := newTestEmulator(t)
+	defer emu.Close()
+	defer conn.Close()
+
+	emu.RegisterFunc("sum", func(_ context.Context, args []interface{}) ([]interface{}, error) {
+		var sum int64
+		for _, arg := range args {
+			sum += arg.(int64)
+		}
+		return []interface{}{sum, "done"}, nil
+	})
+	emu.RegisterFunc("fail", func(context.Context, []interface{}) ([]interface{}, error) {
+		return nil, errors.New("failed")
+	})
+
+	res := conn.Exec(context.Background(), &Call17{Name: "sum", Tuple: []interface{}{int64(1), int64(2)}})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{int64(3)}, {"done"}}, res.Data)
+
+	res = conn.Exec(context.Background(), &Call{Name: "sum", Tuple: []interface{}{int64(3), int64(4)}})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{int64(7)}, {"done"}}, res.Data)
+
+	res = conn.Exec(context.Background(), &Call17{Name: "fail"})
+	assert.Equal(ErrProcLua, res.ErrorCode)
+	assert.Contains(res.Error.Error(), "failed")
+
+	res = conn.Exec(context.Background(), &Call17{Name: "missing"})
+	assert.Equal(ErrNoSuchProc, res.ErrorCode)
+
+	// spaces created later are found after the schema is reloaded
+	require.NoError(emu.CreateSpace(513, "later", nil, EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{0}}))
+	res = conn.Exec(context.Background(), &Replace{Space: "later", Tuple: []interface{}{"key"}})
+	require.NoError(res.Error)
+
+	assert.Error(emu.CreateSpace(513, "again", nil, EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{0}}))
+	assert.Error(emu.CreateSpace(514, "nopk", nil, EmulatorIndex{Name: "primary", Parts: []uint{0}}))
+}
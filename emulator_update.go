@@ -0,0 +1,211 @@
+package tarantool
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// applyOperators returns the updated copy of the tuple.
+func (s *emuSpace) applyOperators(tuple []interface{}, ops []Operator) ([]interface{}, error) {
+	updated := append([]interface{}(nil), tuple...)
+	for _, op := range ops {
+		var err error
+		if updated, err = s.applyOperator(updated, op); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+func (s *emuSpace) applyOperator(tuple []interface{}, op Operator) ([]interface{}, error) {
+	switch op := op.(type) {
+	case *OpInsert:
+		pos, err := s.fieldPos(operatorField(op.Before, op.BeforeName), len(tuple)+1, len(tuple)+1)
+		if err != nil {
+			return nil, err
+		}
+		tuple = append(tuple, nil)
+		copy(tuple[pos+1:], tuple[pos:])
+		tuple[pos] = op.Argument
+		return tuple, nil
+	case *OpAssign:
+		pos, err := s.fieldPos(operatorField(op.Field, op.FieldName), len(tuple), len(tuple)+1)
+		if err != nil {
+			return nil, err
+		}
+		if pos == len(tuple) {
+			return append(tuple, op.Argument), nil
+		}
+		tuple[pos] = op.Argument
+		return tuple, nil
+	case *OpDelete:
+		pos, err := s.fieldPos(operatorField(op.From, op.FromName), len(tuple), len(tuple))
+		if err != nil {
+			return nil, err
+		}
+		if op.Count == 0 {
+			return nil, updateFieldError(pos, "cannot delete 0 fields")
+		}
+		end := len(tuple)
+		if op.Count < uint64(end-pos) {
+			end = pos + int(op.Count)
+		}
+		return append(tuple[:pos], tuple[end:]...), nil
+	}
+
+	var field interface{}
+	switch op := op.(type) {
+	case *OpAdd:
+		field = operatorField(op.Field, op.FieldName)
+	case *OpSub:
+		field = operatorField(op.Field, op.FieldName)
+	case *OpBitAND:
+		field = operatorField(op.Field, op.FieldName)
+	case *OpBitXOR:
+		field = operatorField(op.Field, op.FieldName)
+	case *OpBitOR:
+		field = operatorField(op.Field, op.FieldName)
+	case *OpSplice:
+		field = operatorField(op.Field, op.FieldName)
+	default:
+		return nil, NewQueryError(ErrIllegalParams, fmt.Sprintf("Illegal parameters, unknown operation %T", op))
+	}
+
+	pos, err := s.fieldPos(field, len(tuple), len(tuple))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op := op.(type) {
+	case *OpAdd:
+		tuple[pos], err = addNumbers(pos, '+', tuple[pos], op.Argument)
+	case *OpSub:
+		tuple[pos], err = addNumbers(pos, '-', tuple[pos], op.Argument)
+	case *OpBitAND:
+		tuple[pos], err = bitOperation(pos, '&', tuple[pos], op.Argument)
+	case *OpBitXOR:
+		tuple[pos], err = bitOperation(pos, '^', tuple[pos], op.Argument)
+	case *OpBitOR:
+		tuple[pos], err = bitOperation(pos, '|', tuple[pos], op.Argument)
+	case *OpSplice:
+		tuple[pos], err = splice(pos, tuple[pos], op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tuple, nil
+}
+
+// fieldPos resolves the field number or name of the operator to the position below limit,
+// negative numbers are counted back from end.
+func (s *emuSpace) fieldPos(field interface{}, end, limit int) (int, error) {
+	var pos int64
+
+	switch field := field.(type) {
+	case string:
+		fieldNo, ok := s.fieldNo(field)
+		if !ok {
+			return 0, NewQueryError(ErrNoSuchFieldName, fmt.Sprintf("Field '%s' was not found in the tuple", field))
+		}
+		pos = int64(fieldNo)
+	default:
+		n, ok := toEmuNumber(field)
+		if !ok || n.isFloat || n.isUint {
+			return 0, NewQueryError(ErrIllegalParams, fmt.Sprintf("Illegal parameters, invalid field %v", field))
+		}
+		pos = n.i
+	}
+
+	if pos < 0 {
+		pos += int64(end)
+	}
+	if pos < 0 || pos >= int64(limit) {
+		return 0, NewQueryError(ErrNoSuchField, fmt.Sprintf("Field %v was not found in the tuple", field))
+	}
+	return int(pos), nil
+}
+
+func updateFieldError(pos int, message string) error {
+	return NewQueryError(ErrUpdateField, fmt.Sprintf("Field %d UPDATE error: %s", pos, message))
+}
+
+func argTypeError(pos int, op rune, expected string) error {
+	return NewQueryError(ErrArgType, fmt.Sprintf("Argument type in operation '%c' on field %d does not match field type: expected %s", op, pos, expected))
+}
+
+func addNumbers(pos int, op rune, value interface{}, argument int64) (interface{}, error) {
+	n, ok := toEmuNumber(value)
+	if !ok {
+		return nil, argTypeError(pos, op, "a number")
+	}
+	if n.isFloat {
+		if op == '-' {
+			return n.f - float64(argument), nil
+		}
+		return n.f + float64(argument), nil
+	}
+
+	sum := new(big.Int)
+	if n.isUint {
+		sum.SetUint64(n.u)
+	} else {
+		sum.SetInt64(n.i)
+	}
+	if op == '-' {
+		sum.Sub(sum, big.NewInt(argument))
+	} else {
+		sum.Add(sum, big.NewInt(argument))
+	}
+
+	switch {
+	case sum.IsInt64():
+		return sum.Int64(), nil
+	case sum.IsUint64():
+		return sum.Uint64(), nil
+	}
+	return nil, NewQueryError(ErrUpdateIntegerOverflow, fmt.Sprintf("Integer overflow when performing '%c' operation on field %d", op, pos))
+}
+
+func bitOperation(pos int, op rune, value interface{}, argument uint64) (interface{}, error) {
+	n, ok := toEmuNumber(value)
+	if !ok || n.isFloat || (!n.isUint && n.i < 0) {
+		return nil, argTypeError(pos, op, "a positive integer")
+	}
+
+	u := n.u
+	if !n.isUint {
+		u = uint64(n.i)
+	}
+
+	switch op {
+	case '&':
+		u &= argument
+	case '^':
+		u ^= argument
+	case '|':
+		u |= argument
+	}
+	if u > math.MaxInt64 {
+		return u, nil
+	}
+	return int64(u), nil
+}
+
+// splice replaces Offset characters of the string at Position with Argument.
+func splice(pos int, value interface{}, op *OpSplice) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, argTypeError(pos, ':', "a string")
+	}
+
+	start := len(str)
+	if op.Position < uint64(start) {
+		start = int(op.Position)
+	}
+	end := len(str)
+	if op.Offset < uint64(end-start) {
+		end = start + int(op.Offset)
+	}
+	return str[:start] + op.Argument + str[end:], nil
+}
@@ -19,9 +19,15 @@ var (
 	ErrUnknownError = NewQueryError(ErrUnknown, "unknown error")
 	// ErrOldVersionAnon is returns when tarantool version doesn't support anonymous replication.
 	ErrOldVersionAnon = errors.New("tarantool version is too old for anonymous replication. Min version is 2.3.1")
+	// ErrNoPositionStore is returned by Slave.Resume and Slave.SavePosition if the position store isn't set.
+	ErrNoPositionStore = errors.New("position store is not set")
 
 	// ErrConnectionClosed returns when connection is no longer alive.
 	ErrConnectionClosed = errors.New("connection closed")
+	// ErrReconnecting is returned by Connector in fail-fast mode while the connection is being restored.
+	ErrReconnecting = errors.New("reconnecting")
+	// ErrWatchersNotSupported is returned by NewWatcher if the server doesn't support IPROTO_WATCH.
+	ErrWatchersNotSupported = errors.New("watchers are not supported by the server. Min version is 2.10.0")
 )
 
 // Error has Temporary method which returns true if error is temporary.
@@ -32,6 +32,28 @@ func ResultModeExecOption(mode resultUnmarshalMode) ExecOption {
 	return &resultModeOption{mode}
 }
 
+type resultDestOption struct {
+	dest interface{}
+}
+
+func (o *resultDestOption) apply(r *request) {
+	r.resultDest = o.dest
+}
+
+// ResultIntoExecOption decodes the tuples of the result into dest instead of Result.Data.
+// The dest is a pointer to a slice of structs or pointers to structs, or a pointer to a struct
+// which receives the first tuple or is zeroed if there is none. Tuple fields are mapped to exported struct fields in their order,
+// the `tarantool:"name"` tag names the field for SQL columns and maps, `tarantool:"-"` skips it.
+func ResultIntoExecOption(dest interface{}) ExecOption {
+	return &resultDestOption{dest}
+}
+
+type schemalessOption struct{}
+
+func (o *schemalessOption) apply(r *request) {
+	r.schemaless = true
+}
+
 var (
 	ExecResultAsRawData          = ResultModeExecOption(ResultAsRawData)
 	ExecResultAsDataWithFallback = ResultModeExecOption(ResultAsDataWithFallback)
@@ -45,13 +67,22 @@ func (conn *Connection) writeRequest(ctx context.Context, request *request, q Qu
 
 	pp := packetPool.GetWithID(requestID)
 
-	if err = pp.packMsg(q, conn.packData); err != nil {
+	data, schemaID := conn.schema()
+	if err = pp.packMsg(q, data); err != nil {
+		code := ErrInvalidMsgpack
+		if e, ok := err.(*unknownNameError); ok {
+			code = e.code
+		}
 		return nil, &Result{
-			Error:     NewQueryError(ErrInvalidMsgpack, err.Error()),
-			ErrorCode: ErrInvalidMsgpack,
+			Error:     NewQueryError(code, err.Error()),
+			ErrorCode: code,
 		}, 0
 	}
 
+	if !request.schemaless {
+		pp.packet.SchemaID = schemaID
+	}
+	pp.packet.StreamID = request.streamID
 	request.packet = pp
 
 	if oldRequest := conn.requests.Put(requestID, request); oldRequest != nil {
@@ -129,12 +160,32 @@ func (conn *Connection) readResult(ctx context.Context, arc chan *AsyncResult, r
 
 func (conn *Connection) Exec(ctx context.Context, q Query, options ...ExecOption) (result *Result) {
 	var cancel context.CancelFunc = func() {}
-	var requestID uint64
-	var rerr *Result
+	var schemaID uint64
 
 	if conn.queryTimeout != 0 {
 		ctx, cancel = context.WithTimeout(ctx, conn.queryTimeout)
 	}
+	defer cancel()
+
+	result, schemaID = conn.exec(ctx, q, options)
+
+	// the request is repeated once if the schema has been changed
+	// since it was loaded, schemaID is 0 if nothing has been sent
+	stale := result.ErrorCode == ErrWrongSchemaVaersion
+	if schemaID == 0 && (result.ErrorCode == ErrNoSuchSpace || result.ErrorCode == ErrNoSuchIndexName) {
+		stale = true
+	}
+	if stale && conn.reloadSchema(ctx) == nil {
+		result, _ = conn.exec(ctx, q, options)
+	}
+
+	return result
+}
+
+// exec returns the result along with the schema id of the response.
+func (conn *Connection) exec(ctx context.Context, q Query, options []ExecOption) (result *Result, schemaID uint64) {
+	var requestID uint64
+	var rerr *Result
 
 	replyChan := make(chan *AsyncResult, 1)
 
@@ -146,18 +197,16 @@ func (conn *Connection) Exec(ctx context.Context, q Query, options ...ExecOption
 	}
 
 	if _, rerr, requestID = conn.writeRequest(ctx, request, q); rerr != nil {
-		cancel()
-		return rerr
+		return rerr, 0
 	}
 
 	ar := conn.readResult(ctx, replyChan, requestID)
-	cancel()
 
 	if rerr := ar.Error; rerr != nil {
 		return &Result{
 			Error:     rerr,
 			ErrorCode: ar.ErrorCode,
-		}
+		}, 0
 	}
 
 	pp := ar.BinaryPacket
@@ -165,7 +214,7 @@ func (conn *Connection) Exec(ctx context.Context, q Query, options ...ExecOption
 		return &Result{
 			Error:     ConnectionClosedError(conn),
 			ErrorCode: ErrNoConnection,
-		}
+		}, 0
 	}
 
 	if err := pp.Unmarshal(); err != nil {
@@ -178,10 +227,11 @@ func (conn *Connection) Exec(ctx context.Context, q Query, options ...ExecOption
 		if result == nil {
 			result = &Result{}
 		}
+		schemaID = pp.packet.SchemaID
 	}
 	pp.Release()
 
-	return result
+	return result, schemaID
 }
 
 func (conn *Connection) ExecAsync(
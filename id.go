@@ -0,0 +1,179 @@
+package tarantool
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ProtocolFeature is an optional IPROTO feature negotiated with IPROTO_ID.
+type ProtocolFeature uint64
+
+const (
+	FeatureStreams            ProtocolFeature = 0
+	FeatureTransactions       ProtocolFeature = 1
+	FeatureErrorExtension     ProtocolFeature = 2
+	FeatureWatchers           ProtocolFeature = 3
+	FeaturePagination         ProtocolFeature = 4
+	FeatureSpaceAndIndexNames ProtocolFeature = 5
+	FeatureWatchOnce          ProtocolFeature = 6
+)
+
+func (f ProtocolFeature) String() string {
+	switch f {
+	case FeatureStreams:
+		return "streams"
+	case FeatureTransactions:
+		return "transactions"
+	case FeatureErrorExtension:
+		return "error_extension"
+	case FeatureWatchers:
+		return "watchers"
+	case FeaturePagination:
+		return "pagination"
+	case FeatureSpaceAndIndexNames:
+		return "space_and_index_names"
+	case FeatureWatchOnce:
+		return "watch_once"
+	}
+	return "unknown"
+}
+
+// ProtocolVersion is the IPROTO protocol version implemented by the package.
+const ProtocolVersion = uint64(3)
+
+// clientFeatures are announced by Connection in IPROTO_ID request
+var clientFeatures = []ProtocolFeature{
+	FeatureStreams,
+	FeatureTransactions,
+	FeatureErrorExtension,
+	FeatureWatchers,
+}
+
+// ProtocolInfo describes the protocol version and the features supported by a peer.
+type ProtocolInfo struct {
+	Version  uint64
+	Features []ProtocolFeature
+	// AuthType is the default authentication method of the server, e.g. AuthPapSha256.
+	// It is sent by Tarantool >= 2.11.0
+	AuthType string
+}
+
+// Has checks whether the feature is supported.
+func (info ProtocolInfo) Has(feature ProtocolFeature) bool {
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// intersect returns the protocol info supported by both sides.
+func (info ProtocolInfo) intersect(other ProtocolInfo) ProtocolInfo {
+	res := ProtocolInfo{Version: info.Version, AuthType: info.AuthType}
+	if res.AuthType == "" {
+		res.AuthType = other.AuthType
+	}
+	if other.Version < res.Version {
+		res.Version = other.Version
+	}
+	for _, f := range info.Features {
+		if other.Has(f) {
+			res.Features = append(res.Features, f)
+		}
+	}
+	return res
+}
+
+// ID is the IPROTO_ID request used to negotiate the protocol version and features.
+// The response body has the same format and is available through IDResponse.
+// It is available since Tarantool >= 2.10.0
+type ID struct {
+	ProtocolInfo
+}
+
+var _ Query = (*ID)(nil)
+
+func (q *ID) GetCommandID() uint {
+	return IDCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *ID) MarshalMsg(b []byte) (o []byte, err error) {
+	o = b
+	if q.AuthType != "" {
+		o = msgp.AppendMapHeader(o, 3)
+		o = msgp.AppendUint(o, KeyAuthType)
+		o = msgp.AppendString(o, q.AuthType)
+	} else {
+		o = msgp.AppendMapHeader(o, 2)
+	}
+
+	o = msgp.AppendUint(o, KeyVersion)
+	o = msgp.AppendUint64(o, q.Version)
+
+	o = msgp.AppendUint(o, KeyFeatures)
+	o = msgp.AppendArrayHeader(o, uint32(len(q.Features)))
+	for _, f := range q.Features {
+		o = msgp.AppendUint64(o, uint64(f))
+	}
+
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *ID) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var i, n uint32
+	var k uint
+	var f uint64
+
+	q.Version = 0
+	q.Features = nil
+	q.AuthType = ""
+
+	buf = data
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeyVersion:
+			if q.Version, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		case KeyFeatures:
+			if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+				return
+			}
+			q.Features = make([]ProtocolFeature, 0, n)
+			for ; n > 0; n-- {
+				if f, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+					return
+				}
+				q.Features = append(q.Features, ProtocolFeature(f))
+			}
+		case KeyAuthType:
+			if q.AuthType, buf, err = msgp.ReadStringBytes(buf); err != nil {
+				return
+			}
+		default:
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// IDResponse is the body of the response to the IPROTO_ID request.
+type IDResponse struct {
+	ID
+}
+
+func (r *IDResponse) GetCommandID() uint {
+	return OKCommand
+}
@@ -0,0 +1,75 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDPackUnpack(t *testing.T) {
+	require := require.New(t)
+
+	q := &ID{ProtocolInfo{
+		Version:  ProtocolVersion,
+		Features: []ProtocolFeature{FeatureStreams, FeatureWatchers},
+	}}
+
+	buf, err := q.MarshalMsg(nil)
+	require.NoError(err)
+
+	q2 := &ID{}
+	rest, err := q2.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Empty(rest)
+	require.Equal(q, q2)
+	require.True(q2.Has(FeatureWatchers))
+	require.False(q2.Has(FeatureTransactions))
+}
+
+func TestIDNegotiation(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newIprotoListenerWithOptions(t, func(context.Context, Query) *Result {
+		return &Result{}
+	}, &IprotoServerOptions{
+		ProtocolInfo: &ProtocolInfo{
+			Version:  2,
+			Features: []ProtocolFeature{FeatureStreams, FeaturePagination},
+		},
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	info := conn.ProtocolInfo()
+	assert.Equal(uint64(2), info.Version)
+	assert.Equal([]ProtocolFeature{FeatureStreams}, info.Features)
+
+	l.Lock()
+	server := l.servers[0]
+	l.Unlock()
+	assert.Equal(info, server.ProtocolInfo())
+
+	res := conn.Exec(context.Background(), &Ping{})
+	assert.NoError(res.Error)
+}
+
+func TestIDUnsupported(t *testing.T) {
+	require := require.New(t)
+
+	l := newIprotoListener(t, func(context.Context, Query) *Result {
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	require.Equal(ProtocolInfo{}, conn.ProtocolInfo())
+}
@@ -0,0 +1,85 @@
+package tarantool
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// extTypes are Tarantool extension types decoded by readIntfBytes.
+// They can't be registered with msgp.RegisterExtension because msgp reserves some of their ids.
+var extTypes = map[int8]func() msgp.Extension{
+	ExtDecimal:  func() msgp.Extension { return &Decimal{} },
+	ExtUUID:     func() msgp.Extension { return &UUID{} },
+	ExtError:    func() msgp.Extension { return &BoxError{} },
+	ExtDatetime: func() msgp.Extension { return &Datetime{} },
+	ExtInterval: func() msgp.Extension { return &Interval{} },
+}
+
+// peekExtType returns the type of the extension at the beginning of b.
+func peekExtType(b []byte) (int8, bool) {
+	if len(b) < 2 {
+		return 0, false
+	}
+	switch b[0] {
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return int8(b[1]), true
+	case 0xc7: // ext 8
+		if len(b) > 2 {
+			return int8(b[2]), true
+		}
+	case 0xc8: // ext 16
+		if len(b) > 3 {
+			return int8(b[3]), true
+		}
+	case 0xc9: // ext 32
+		if len(b) > 5 {
+			return int8(b[5]), true
+		}
+	}
+	return 0, false
+}
+
+// readIntfBytes is msgp.ReadIntfBytes that is aware of Tarantool extension types.
+// Values of these types are returned as *BoxError etc. and the rest is decoded the same way.
+func readIntfBytes(b []byte) (i interface{}, o []byte, err error) {
+	switch msgp.NextType(b) {
+	case msgp.ArrayType:
+		var sz uint32
+		if sz, o, err = msgp.ReadArrayHeaderBytes(b); err != nil {
+			return
+		}
+		arr := make([]interface{}, sz)
+		for j := range arr {
+			if arr[j], o, err = readIntfBytes(o); err != nil {
+				return
+			}
+		}
+		return arr, o, nil
+	case msgp.MapType:
+		var sz uint32
+		var key []byte
+		if sz, o, err = msgp.ReadMapHeaderBytes(b); err != nil {
+			return
+		}
+		m := make(map[string]interface{}, sz)
+		for ; sz > 0; sz-- {
+			if key, o, err = msgp.ReadMapKeyZC(o); err != nil {
+				return
+			}
+			if m[string(key)], o, err = readIntfBytes(o); err != nil {
+				return
+			}
+		}
+		return m, o, nil
+	case msgp.ExtensionType, msgp.Complex64Type, msgp.Complex128Type, msgp.TimeType:
+		if t, ok := peekExtType(b); ok {
+			if f, ok := extTypes[t]; ok {
+				e := f()
+				if o, err = msgp.ReadExtensionBytes(b, e); err != nil {
+					return
+				}
+				return e, o, nil
+			}
+		}
+	}
+	return msgp.ReadIntfBytes(b)
+}
@@ -0,0 +1,149 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestDecimal(t *testing.T) {
+	require := require.New(t)
+
+	for _, tc := range []struct {
+		in    string
+		out   string
+		bytes []byte
+	}{
+		// examples from the Tarantool MP_DECIMAL documentation
+		{"-12.34", "-12.34", []byte{0x02, 0x01, 0x23, 0x4d}},
+		{"0.00000000000000000000000000000000000010", "0.00000000000000000000000000000000000010", []byte{0x26, 0x01, 0x0c}},
+		{"0", "0", []byte{0x00, 0x0c}},
+		{"123", "123", []byte{0x00, 0x12, 0x3c}},
+		{"12e2", "1200", []byte{0xfe, 0x01, 0x2c}},
+	} {
+		d, err := ParseDecimal(tc.in)
+		require.NoError(err, tc.in)
+
+		b := make([]byte, d.Len())
+		require.NoError(d.MarshalBinaryTo(b))
+		require.Equal(tc.bytes, b, tc.in)
+
+		d2 := &Decimal{}
+		require.NoError(d2.UnmarshalBinary(tc.bytes))
+		require.Equal(tc.out, d2.String())
+	}
+
+	require.Equal(123.45, NewDecimal(12345, 2).Float64())
+	require.Equal("-0.05", NewDecimal(-5, 2).String())
+
+	for _, s := range []string{"", "-", "1.2.3", "1-2", "abc", "1e"} {
+		_, err := ParseDecimal(s)
+		require.Equal(ErrBadDecimal, err, s)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	require := require.New(t)
+
+	s := "c8f0fa1f-da29-438c-a040-393f1126ad39"
+	u, err := ParseUUID(s)
+	require.NoError(err)
+	require.Equal(s, u.String())
+
+	_, err = ParseUUID("c8f0fa1f-da29-438c-a040-393f1126ad3")
+	require.Equal(ErrBadUUID, err)
+	_, err = ParseUUID("c8f0fa1fxda29-438c-a040-393f1126ad39")
+	require.Equal(ErrBadUUID, err)
+}
+
+func TestDatetime(t *testing.T) {
+	require := require.New(t)
+
+	utc := NewDatetime(time.Date(2022, 1, 31, 12, 0, 0, 0, time.UTC))
+	require.Equal(8, utc.Len())
+
+	msk := NewDatetime(time.Date(2022, 1, 31, 12, 0, 0, 500, time.FixedZone("", 3*3600)))
+	require.Equal(16, msk.Len())
+
+	for _, d := range []*Datetime{utc, msk} {
+		b := make([]byte, d.Len())
+		require.NoError(d.MarshalBinaryTo(b))
+
+		d2 := &Datetime{}
+		require.NoError(d2.UnmarshalBinary(b))
+		require.True(d.Time.Equal(d2.Time))
+		_, offset := d2.Time.Zone()
+		_, expected := d.Time.Zone()
+		require.Equal(expected, offset)
+	}
+
+	require.Equal(ErrBadDatetime, (&Datetime{}).UnmarshalBinary([]byte{1, 2, 3}))
+}
+
+func TestInterval(t *testing.T) {
+	require := require.New(t)
+
+	iv := &Interval{Year: 1, Day: -2, Nsec: 1000, Adjust: AdjustLast}
+	b := make([]byte, iv.Len())
+	require.NoError(iv.MarshalBinaryTo(b))
+
+	iv2 := &Interval{Hour: 5}
+	require.NoError(iv2.UnmarshalBinary(b))
+	require.Equal(iv, iv2)
+}
+
+func TestExtensionsInTuple(t *testing.T) {
+	require := require.New(t)
+
+	dec, err := ParseDecimal("-12.34")
+	require.NoError(err)
+	u, err := ParseUUID("c8f0fa1f-da29-438c-a040-393f1126ad39")
+	require.NoError(err)
+	dt := NewDatetime(time.Unix(1643630400, 0).UTC())
+	iv := &Interval{Month: 1, Adjust: AdjustNone}
+
+	tuple := []interface{}{int64(1), dec, u, dt, iv, map[string]interface{}{"nested": dec}}
+
+	q := &Insert{Space: 1, Tuple: tuple}
+	buf, err := q.MarshalMsg(nil)
+	require.NoError(err)
+
+	q2 := &Insert{}
+	_, err = q2.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal(tuple, q2.Tuple)
+
+	// values of unknown extension types are left to msgp
+	raw, err := msgp.AppendExtension(nil, &msgp.RawExtension{Type: 42, Data: []byte{1}})
+	require.NoError(err)
+	v, _, err := readIntfBytes(raw)
+	require.NoError(err)
+	require.Equal(&msgp.RawExtension{Type: 42, Data: []byte{1}}, v)
+}
+
+func TestIprotoServerExtensions(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newIprotoListener(t, func(_ context.Context, q Query) *Result {
+		if ins, ok := q.(*Insert); ok {
+			return &Result{Data: [][]interface{}{ins.Tuple}}
+		}
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	dec := NewDecimal(100500, 2)
+	res := conn.Exec(context.Background(), &Insert{Space: 1, Tuple: []interface{}{uint64(1), dec}})
+	require.NoError(res.Error)
+	require.Len(res.Data, 1)
+	assert.Equal("1005.00", res.Data[0][1].(*Decimal).String())
+}
@@ -0,0 +1,151 @@
+package tarantool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Middleware wraps QueryHandler, e.g. to log the requests or to collect metrics.
+type Middleware func(next QueryHandler) QueryHandler
+
+// ServeMux is QueryHandler routing the requests to the handlers registered
+// by function name, by space and by command, in this order of precedence.
+// Unmatched calls get ErrNoSuchProc, other unmatched requests get ErrUnsupported.
+// Note that Connection selects ViewSpace and ViewIndex on connect, so they have to be handled too.
+//
+//	mux := NewServeMux()
+//	mux.DefineSpace(512, "users")
+//	mux.HandleSpace("users", usersHandler)
+//	mux.HandleFunc("version", versionHandler)
+//	mux.Handle(EvalCommand, evalHandler)
+//	mux.Use(logMiddleware)
+//	server := NewIprotoServer(uuid, mux.Serve, nil)
+type ServeMux struct {
+	sync.RWMutex
+	commands    map[uint]QueryHandler
+	spaces      map[uint]QueryHandler
+	namedSpaces map[string]QueryHandler
+	spaceNames  map[uint]string
+	funcs       map[string]QueryHandler
+	middleware  []Middleware
+}
+
+func NewServeMux() *ServeMux {
+	return &ServeMux{
+		commands:    make(map[uint]QueryHandler),
+		spaces:      make(map[uint]QueryHandler),
+		namedSpaces: make(map[string]QueryHandler),
+		spaceNames:  make(map[uint]string),
+		funcs:       make(map[string]QueryHandler),
+	}
+}
+
+// Handle registers the handler of the command, e.g. SelectCommand or EvalCommand.
+func (mux *ServeMux) Handle(command uint, handler QueryHandler) {
+	mux.Lock()
+	defer mux.Unlock()
+	mux.commands[command] = handler
+}
+
+// HandleSpace registers the handler of Select, Insert, Replace, Update, Upsert
+// and Delete requests to the space given by id or by name, see DefineSpace.
+// It panics if the space is neither a number nor a string.
+func (mux *ServeMux) HandleSpace(space interface{}, handler QueryHandler) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if name, ok := space.(string); ok {
+		mux.namedSpaces[name] = handler
+		return
+	}
+
+	id, err := numberToUint64(space)
+	if err != nil {
+		panic(fmt.Sprintf("tarantool: invalid space %v: %s", space, err))
+	}
+	mux.spaces[uint(id)] = handler
+}
+
+// HandleFunc registers the handler of Call and Call17 requests of the function.
+func (mux *ServeMux) HandleFunc(name string, handler QueryHandler) {
+	mux.Lock()
+	defer mux.Unlock()
+	mux.funcs[name] = handler
+}
+
+// DefineSpace names the space, so its handler can be registered by name.
+// The requests decoded by the server refer to the spaces by id only.
+func (mux *ServeMux) DefineSpace(id uint, name string) {
+	mux.Lock()
+	defer mux.Unlock()
+	mux.spaceNames[id] = name
+}
+
+// Use appends the middleware to the chain wrapping the matched handlers,
+// the middleware added first is called first.
+func (mux *ServeMux) Use(middleware ...Middleware) {
+	mux.Lock()
+	defer mux.Unlock()
+	mux.middleware = append(mux.middleware, middleware...)
+}
+
+// Serve implements QueryHandler.
+func (mux *ServeMux) Serve(ctx context.Context, q Query) *Result {
+	mux.RLock()
+	handler := mux.match(q)
+	for i := len(mux.middleware) - 1; i >= 0; i-- {
+		handler = mux.middleware[i](handler)
+	}
+	mux.RUnlock()
+
+	return handler(ctx, q)
+}
+
+func (mux *ServeMux) match(q Query) QueryHandler {
+	var name string
+
+	switch q := q.(type) {
+	case *Call:
+		name = q.Name
+	case *Call17:
+		name = q.Name
+	}
+	if name != "" {
+		if handler, ok := mux.funcs[name]; ok {
+			return handler
+		}
+	}
+
+	if id, ok := querySpace(q); ok {
+		if handler, ok := mux.spaces[id]; ok {
+			return handler
+		}
+		if name, ok := mux.spaceNames[id]; ok {
+			if handler, ok := mux.namedSpaces[name]; ok {
+				return handler
+			}
+		}
+	}
+
+	if handler, ok := mux.commands[q.GetCommandID()]; ok {
+		return handler
+	}
+
+	if name != "" {
+		return func(context.Context, Query) *Result {
+			return &Result{
+				ErrorCode: ErrNoSuchProc,
+				Error:     NewQueryError(ErrNoSuchProc, fmt.Sprintf("Procedure '%s' is not defined", name)),
+			}
+		}
+	}
+	return unsupportedHandler
+}
+
+func unsupportedHandler(_ context.Context, q Query) *Result {
+	return &Result{
+		ErrorCode: ErrUnsupported,
+		Error:     NewQueryError(ErrUnsupported, fmt.Sprintf("Command %d is not supported", q.GetCommandID())),
+	}
+}
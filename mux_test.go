@@ -0,0 +1,83 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func muxResult(value string) QueryHandler {
+	return func(context.Context, Query) *Result {
+		return &Result{Data: [][]interface{}{{value}}}
+	}
+}
+
+func TestServeMux(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	var calls []string
+
+	mux := NewServeMux()
+	mux.HandleSpace(ViewSpace, func(context.Context, Query) *Result {
+		return &Result{Data: [][]interface{}{
+			{uint64(512), uint64(1), "users", "memtx", uint64(0), map[string]interface{}{}, []interface{}{}},
+		}}
+	})
+	mux.HandleSpace(uint(ViewIndex), func(context.Context, Query) *Result { return &Result{} })
+	mux.HandleSpace("users", muxResult("users"))
+	mux.DefineSpace(512, "users")
+	mux.HandleSpace(513, muxResult("513"))
+	mux.Handle(SelectCommand, muxResult("select"))
+	mux.Handle(EvalCommand, muxResult("eval"))
+	mux.HandleFunc("version", muxResult("version"))
+	mux.Use(func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, q Query) *Result {
+			calls = append(calls, "first")
+			return next(ctx, q)
+		}
+	}, func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, q Query) *Result {
+			calls = append(calls, "second")
+			return next(ctx, q)
+		}
+	})
+
+	l := newIprotoListenerWithOptions(t, mux.Serve, &IprotoServerOptions{Sequential: true})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	tests := []struct {
+		query    Query
+		expected string
+	}{
+		{&Select{Space: "users", Key: uint64(1)}, "users"},
+		{&Insert{Space: 512, Tuple: []interface{}{uint64(1)}}, "users"},
+		{&Delete{Space: 513, Key: uint64(1)}, "513"},
+		{&Select{Space: 514, Key: uint64(1)}, "select"},
+		{&Eval{Expression: "return 1"}, "eval"},
+		{&Call{Name: "version"}, "version"},
+		{&Call17{Name: "version"}, "version"},
+	}
+	for _, tc := range tests {
+		res := conn.Exec(context.Background(), tc.query)
+		require.NoError(res.Error, "%#v", tc.query)
+		assert.Equal([][]interface{}{{tc.expected}}, res.Data, "%#v", tc.query)
+	}
+
+	res := conn.Exec(context.Background(), &Call17{Name: "missing"})
+	assert.Equal(ErrNoSuchProc, res.ErrorCode)
+	assert.Contains(res.Error.Error(), "Procedure 'missing' is not defined")
+
+	res = conn.Exec(context.Background(), &Replace{Space: 514, Tuple: []interface{}{uint64(1)}})
+	assert.Equal(ErrUnsupported, res.ErrorCode)
+
+	// connect selects _vspace and _vindex, each request passes the middleware in order
+	assert.Equal(2*(len(tests)+4), len(calls))
+	assert.Equal([]string{"first", "second"}, calls[:2])
+}
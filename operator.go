@@ -6,95 +6,124 @@ import (
 	"github.com/tinylib/msgp/msgp"
 )
 
+// Operator is an update operation of Update and Upsert queries.
+// Field, From and Before are numeric positions (starting from 0). FieldName, FromName
+// and BeforeName, if set, take precedence over them; the names are resolved with
+// the space format fetched on connect, unknown names are passed to Tarantool as is, e.g. JSON paths.
 type Operator interface {
 	AsTuple() []interface{}
 }
 
 type OpAdd struct {
-	Field    int64
-	Argument int64
+	Field     int64
+	FieldName string
+	Argument  int64
 }
 
 type OpSub struct {
-	Field    int64
-	Argument int64
+	Field     int64
+	FieldName string
+	Argument  int64
 }
 
 type OpBitAND struct {
-	Field    int64
-	Argument uint64
+	Field     int64
+	FieldName string
+	Argument  uint64
 }
 
 type OpBitXOR struct {
-	Field    int64
-	Argument uint64
+	Field     int64
+	FieldName string
+	Argument  uint64
 }
 
 type OpBitOR struct {
-	Field    int64
-	Argument uint64
+	Field     int64
+	FieldName string
+	Argument  uint64
 }
 
 type OpDelete struct {
-	From  int64
-	Count uint64
+	From     int64
+	FromName string
+	Count    uint64
 }
 
 type OpInsert struct {
-	Before   int64
-	Argument interface{}
+	Before     int64
+	BeforeName string
+	Argument   interface{}
 }
 
 type OpAssign struct {
-	Field    int64
-	Argument interface{}
+	Field     int64
+	FieldName string
+	Argument  interface{}
 }
 
 type OpSplice struct {
-	Field    int64
-	Offset   uint64
-	Position uint64
-	Argument string
+	Field     int64
+	FieldName string
+	Offset    uint64
+	Position  uint64
+	Argument  string
 }
 
 func (op *OpAdd) AsTuple() []interface{} {
-	return []interface{}{"+", op.Field, op.Argument}
+	return []interface{}{"+", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpSub) AsTuple() []interface{} {
-	return []interface{}{"-", op.Field, op.Argument}
+	return []interface{}{"-", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpBitAND) AsTuple() []interface{} {
-	return []interface{}{"&", op.Field, op.Argument}
+	return []interface{}{"&", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpBitXOR) AsTuple() []interface{} {
-	return []interface{}{"^", op.Field, op.Argument}
+	return []interface{}{"^", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpBitOR) AsTuple() []interface{} {
-	return []interface{}{"|", op.Field, op.Argument}
+	return []interface{}{"|", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpDelete) AsTuple() []interface{} {
-	return []interface{}{"#", op.From, op.Count}
+	return []interface{}{"#", operatorField(op.From, op.FromName), op.Count}
 }
 
 func (op *OpInsert) AsTuple() []interface{} {
-	return []interface{}{"!", op.Before, op.Argument}
+	return []interface{}{"!", operatorField(op.Before, op.BeforeName), op.Argument}
 }
 
 func (op *OpAssign) AsTuple() []interface{} {
-	return []interface{}{"=", op.Field, op.Argument}
+	return []interface{}{"=", operatorField(op.Field, op.FieldName), op.Argument}
 }
 
 func (op *OpSplice) AsTuple() []interface{} {
-	return []interface{}{":", op.Field, op.Position, op.Offset, op.Argument}
+	return []interface{}{":", operatorField(op.Field, op.FieldName), op.Position, op.Offset, op.Argument}
 }
 
-func marshalOperator(op Operator, buf []byte) ([]byte, error) {
-	return msgp.AppendIntf(buf, op.AsTuple())
+// operatorField returns the name of the field if it is set and its number otherwise.
+func operatorField(field int64, name string) interface{} {
+	if name != "" {
+		return name
+	}
+	return field
+}
+
+func marshalOperator(op Operator, space interface{}, data *packData, buf []byte) ([]byte, error) {
+	tuple := op.AsTuple()
+	if len(tuple) > 1 {
+		if name, ok := tuple[1].(string); ok {
+			if fieldNo, exists := data.fieldNoByName(space, name); exists {
+				tuple[1] = fieldNo
+			}
+		}
+	}
+	return msgp.AppendIntf(buf, tuple)
 }
 
 func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
@@ -110,8 +139,17 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		return
 	}
 
-	var field0 int64
-	if field0, buf, err = msgp.ReadInt64Bytes(buf); err != nil {
+	// field is either a number or a name
+	var (
+		field0 int64
+		name0  string
+	)
+	if msgp.NextType(buf) == msgp.StrType {
+		name0, buf, err = msgp.ReadStringBytes(buf)
+	} else {
+		field0, buf, err = msgp.ReadInt64Bytes(buf)
+	}
+	if err != nil {
 		return
 	}
 
@@ -120,7 +158,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpAdd: %d", n)
 		}
-		opAdd := &OpAdd{Field: field0}
+		opAdd := &OpAdd{Field: field0, FieldName: name0}
 		if opAdd.Argument, buf, err = msgp.ReadInt64Bytes(buf); err != nil {
 			return
 		}
@@ -129,7 +167,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpSub: %d", n)
 		}
-		opSub := &OpSub{Field: field0}
+		opSub := &OpSub{Field: field0, FieldName: name0}
 		if opSub.Argument, buf, err = msgp.ReadInt64Bytes(buf); err != nil {
 			return
 		}
@@ -138,7 +176,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpBitAND: %d", n)
 		}
-		opAnd := &OpBitAND{Field: field0}
+		opAnd := &OpBitAND{Field: field0, FieldName: name0}
 		if opAnd.Argument, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 			return
 		}
@@ -147,7 +185,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpBitXOR: %d", n)
 		}
-		opXOR := &OpBitXOR{Field: field0}
+		opXOR := &OpBitXOR{Field: field0, FieldName: name0}
 		if opXOR.Argument, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 			return
 		}
@@ -156,7 +194,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpBitOR: %d", n)
 		}
-		opOR := &OpBitOR{Field: field0}
+		opOR := &OpBitOR{Field: field0, FieldName: name0}
 		if opOR.Argument, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 			return
 		}
@@ -165,7 +203,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpDelete: %d", n)
 		}
-		opDel := &OpDelete{From: field0}
+		opDel := &OpDelete{From: field0, FromName: name0}
 		if opDel.Count, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 			return
 		}
@@ -174,8 +212,8 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpInsert: %d", n)
 		}
-		opIns := &OpInsert{Before: field0}
-		if opIns.Argument, buf, err = msgp.ReadIntfBytes(buf); err != nil {
+		opIns := &OpInsert{Before: field0, BeforeName: name0}
+		if opIns.Argument, buf, err = readIntfBytes(buf); err != nil {
 			return
 		}
 		op = opIns
@@ -183,8 +221,8 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 3 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpAssign: %d", n)
 		}
-		opAss := &OpAssign{Field: field0}
-		if opAss.Argument, buf, err = msgp.ReadIntfBytes(buf); err != nil {
+		opAss := &OpAssign{Field: field0, FieldName: name0}
+		if opAss.Argument, buf, err = readIntfBytes(buf); err != nil {
 			return
 		}
 		op = opAss
@@ -192,7 +230,7 @@ func unmarshalOperator(data []byte) (op Operator, buf []byte, err error) {
 		if n != 5 {
 			return nil, buf, fmt.Errorf("unexpected number of arguments in OpSplice: %d", n)
 		}
-		opSpl := &OpSplice{Field: field0}
+		opSpl := &OpSplice{Field: field0, FieldName: name0}
 		if opSpl.Position, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 			return
 		}
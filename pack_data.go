@@ -16,8 +16,21 @@ type packData struct {
 	packedDefaultOffset []byte
 	packedSingleKey     []byte
 	spaceMap            map[string]uint64
+	spaceNames          map[uint64]string
 	indexMap            map[uint64]map[string]uint64
 	primaryKeyMap       map[uint64][]int
+	formatMap           map[uint64][]SpaceField
+	fieldMap            map[uint64]map[string]uint64
+}
+
+// unknownNameError is returned if the space or index name is missing in the schema.
+type unknownNameError struct {
+	code uint
+	msg  string
+}
+
+func (e *unknownNameError) Error() string {
+	return e.msg
 }
 
 type packDataPool struct {
@@ -54,8 +67,11 @@ func newPackData(defaultSpace interface{}) *packData {
 		packedDefaultOffset: encodeValues2(KeyOffset, 0),
 		packedSingleKey:     packSelectSingleKey(),
 		spaceMap:            make(map[string]uint64),
+		spaceNames:          make(map[uint64]string),
 		indexMap:            make(map[uint64]map[string]uint64),
 		primaryKeyMap:       make(map[uint64][]int),
+		formatMap:           make(map[uint64][]SpaceField),
+		fieldMap:            make(map[uint64]map[string]uint64),
 	}
 }
 
@@ -70,7 +86,7 @@ func (data *packData) spaceNo(space interface{}) (uint64, error) {
 		if exists {
 			return spaceNo, nil
 		}
-		return 0, fmt.Errorf("unknown space %#v", space)
+		return 0, &unknownNameError{ErrNoSuchSpace, fmt.Sprintf("unknown space %#v", space)}
 	}
 
 	return numberToUint64(space)
@@ -123,6 +139,28 @@ func (data *packData) fieldNo(field interface{}) (uint64, error) {
 	return numberToUint64(field)
 }
 
+// fieldNoByName returns the position of the named field in the space format.
+func (data *packData) fieldNoByName(space interface{}, name string) (uint64, bool) {
+	spaceNo, err := data.spaceNo(space)
+	if err != nil {
+		return 0, false
+	}
+
+	fieldNo, exists := data.fieldMap[spaceNo][name]
+	return fieldNo, exists
+}
+
+func (data *packData) setSpaceFormat(spaceNo uint64, format []SpaceField) {
+	fields := make(map[string]uint64, len(format))
+	for i, f := range format {
+		if f.Name != "" {
+			fields[f.Name] = uint64(i)
+		}
+	}
+	data.formatMap[spaceNo] = format
+	data.fieldMap[spaceNo] = fields
+}
+
 func (data *packData) indexNo(space interface{}, index interface{}) (uint64, error) {
 	if index == nil {
 		return 0, nil
@@ -136,14 +174,14 @@ func (data *packData) indexNo(space interface{}, index interface{}) (uint64, err
 
 		spaceData, exists := data.indexMap[spaceNo]
 		if !exists {
-			return 0, fmt.Errorf("no indexes defined for space %#v", space)
+			return 0, &unknownNameError{ErrNoSuchIndexName, fmt.Sprintf("no indexes defined for space %#v", space)}
 		}
 
 		indexNo, exists := spaceData[value]
 		if exists {
 			return indexNo, nil
 		}
-		return 0, fmt.Errorf("unknown index %#v", index)
+		return 0, &unknownNameError{ErrNoSuchIndexName, fmt.Sprintf("unknown index %#v", index)}
 	}
 
 	return numberToUint64(index)
@@ -165,6 +203,80 @@ func (data *packData) packIndex(space interface{}, index interface{}, o []byte)
 	return o, nil
 }
 
+// spaceName returns the name of the space by its id.
+func (data *packData) spaceName(id uint64) (string, bool) {
+	name, ok := data.spaceNames[id]
+	return name, ok
+}
+
+// dropSpace removes the space and its indexes from the maps.
+func (data *packData) dropSpace(id uint64) {
+	if name, ok := data.spaceNames[id]; ok {
+		delete(data.spaceMap, name)
+		delete(data.spaceNames, id)
+	}
+	delete(data.indexMap, id)
+	delete(data.primaryKeyMap, id)
+	delete(data.formatMap, id)
+	delete(data.fieldMap, id)
+}
+
+// loadSchema fills the maps with the tuples of _vspace and _vindex.
+func (data *packData) loadSchema(spaces, indexes [][]interface{}) {
+	for _, space := range spaces {
+		spaceID, _ := data.spaceNo(space[0])
+		name := space[2].(string)
+		// the space may be renamed
+		if old, ok := data.spaceNames[spaceID]; ok && old != name {
+			delete(data.spaceMap, old)
+		}
+		data.spaceMap[name] = spaceID
+		data.spaceNames[spaceID] = name
+
+		// format is missing in Tarantool 1.6
+		if len(space) > 6 {
+			if format, ok := space[6].([]interface{}); ok && len(format) > 0 {
+				data.setSpaceFormat(spaceID, parseSpaceFormat(format))
+			}
+		}
+	}
+
+	for _, index := range indexes {
+		spaceID, _ := data.fieldNo(index[0])
+		indexID, _ := data.fieldNo(index[1])
+		indexName := index[2].(string)
+		indexAttr := index[4].(map[string]interface{}) // e.g: {"unique": true}
+		indexFields := index[5].([]interface{})        // e.g: [[0 num] [1 str]]
+
+		indexSpaceMap, exists := data.indexMap[spaceID]
+		if !exists {
+			indexSpaceMap = make(map[string]uint64)
+			data.indexMap[spaceID] = indexSpaceMap
+		}
+		indexSpaceMap[indexName] = indexID
+
+		// build list of primary key field numbers for this space, if the PK is detected
+		if indexAttr != nil && indexID == 0 {
+			if unique, ok := indexAttr["unique"]; ok && unique.(bool) {
+				pk := make([]int, len(indexFields))
+				for i := range indexFields {
+					switch descr := indexFields[i].(type) {
+					case []interface{}:
+						f, _ := data.fieldNo(descr[0])
+						pk[i] = int(f)
+					case map[string]interface{}:
+						f, _ := data.fieldNo(descr["field"])
+						pk[i] = int(f)
+					default:
+						panic("invalid index field format")
+					}
+				}
+				data.primaryKeyMap[spaceID] = pk
+			}
+		}
+	}
+}
+
 func (pool *packDataPool) Put(data *packData) *packData {
 	if data == nil {
 		return nil
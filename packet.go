@@ -12,12 +12,14 @@ type Packet struct {
 	LSN        uint64
 	requestID  uint64
 	SchemaID   uint64
+	StreamID   uint64
 	InstanceID uint32
 	Timestamp  time.Time
 	Request    Query
 	Result     *Result
 
 	ResultUnmarshalMode resultUnmarshalMode
+	resultDest          interface{} // see ResultIntoExecOption
 }
 
 func (pack *Packet) String() string {
@@ -67,6 +69,10 @@ func (pack *Packet) UnmarshalBinaryHeader(data []byte) (buf []byte, err error) {
 			if pack.SchemaID, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 				return
 			}
+		case KeyStreamID:
+			if pack.StreamID, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
 		case KeyLSN:
 			if pack.LSN, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
 				return
@@ -103,7 +109,7 @@ func (pack *Packet) UnmarshalBinaryBody(data []byte) (buf []byte, err error) {
 
 	unpackr := func(errorCode uint, data []byte) (buf []byte, err error) {
 		buf = data
-		res := &Result{ErrorCode: errorCode, unmarshalMode: pack.ResultUnmarshalMode}
+		res := &Result{ErrorCode: errorCode, unmarshalMode: pack.ResultUnmarshalMode, dest: pack.resultDest}
 		if buf, err = res.UnmarshalMsg(buf); err != nil {
 			return
 		}
@@ -130,7 +136,7 @@ func (pack *Packet) UnmarshalBinary(data []byte) error {
 
 // UnmarshalMsg implements msgp.Unmarshaler
 func (pack *Packet) UnmarshalMsg(data []byte) (buf []byte, err error) {
-	*pack = Packet{ResultUnmarshalMode: pack.ResultUnmarshalMode}
+	*pack = Packet{ResultUnmarshalMode: pack.ResultUnmarshalMode, resultDest: pack.resultDest}
 
 	buf = data
 
@@ -0,0 +1,312 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNoPoolInstance is returned by Pool when there is no alive instance for the requested mode.
+	ErrNoPoolInstance = NewQueryError(ErrNoConnection, "no alive instance for the requested mode")
+	// ErrEmptyPool is returned by NewPool if no DSN has been given.
+	ErrEmptyPool = errors.New("empty list of instances")
+)
+
+// PoolMode defines which instances of the replica set may serve a query.
+type PoolMode int
+
+const (
+	ModeAny      PoolMode = iota // any alive instance
+	ModeRW                       // master only
+	ModeRO                       // replicas only
+	ModePreferRO                 // replicas, or master if no replica is alive
+)
+
+// PoolBalancer selects an instance among the suitable ones.
+type PoolBalancer int
+
+const (
+	BalanceRoundRobin PoolBalancer = iota
+	BalanceLeastInflight
+)
+
+// RoleCheckFn reports whether the instance behind conn is read-only.
+type RoleCheckFn func(ctx context.Context, conn *Connection) (ro bool, err error)
+
+type PoolOptions struct {
+	// Options are used for every instance of the pool.
+	Options
+	Balancer PoolBalancer
+	// CheckInterval is the period of instance roles re-detection.
+	CheckInterval time.Duration
+	// RoleCheck overrides the default role detection that evaluates box.info.ro.
+	RoleCheck RoleCheckFn
+}
+
+const (
+	roleUnknown int32 = iota
+	roleMaster
+	roleReplica
+)
+
+type poolInstance struct {
+	connector *Connector
+	role      int32
+	inflight  int64
+}
+
+// Pool routes queries across the instances of a replica set depending on their roles.
+// Writes go to the master, selects go to replicas.
+type Pool struct {
+	instances []*poolInstance
+	opts      PoolOptions
+	rr        uint64
+	refresh   chan struct{}
+	exit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPool creates a Pool for the given instances and detects their roles.
+// Unreachable instances are not an error, they are checked again every CheckInterval.
+func NewPool(dsns []string, options *PoolOptions) (*Pool, error) {
+	if len(dsns) == 0 {
+		return nil, ErrEmptyPool
+	}
+
+	var opts PoolOptions
+	if options != nil {
+		opts = *options
+	}
+	if opts.CheckInterval.Nanoseconds() == 0 {
+		opts.CheckInterval = DefaultPoolCheckInterval
+	}
+	if opts.RoleCheck == nil {
+		opts.RoleCheck = evalRoleCheck
+	}
+
+	p := &Pool{
+		instances: make([]*poolInstance, len(dsns)),
+		opts:      opts,
+		refresh:   make(chan struct{}, 1),
+		exit:      make(chan struct{}),
+	}
+	for i, dsn := range dsns {
+		connOpts := opts.Options
+		p.instances[i] = &poolInstance{connector: New(dsn, &connOpts)}
+	}
+
+	connectTimeout := opts.ConnectTimeout
+	if connectTimeout.Nanoseconds() == 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	p.Refresh(ctx)
+	cancel()
+
+	p.wg.Add(1)
+	go p.checker()
+
+	return p, nil
+}
+
+func evalRoleCheck(ctx context.Context, conn *Connection) (bool, error) {
+	res := conn.Exec(ctx, &Eval{Expression: "return box.info.ro"})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if len(res.Data) == 0 || len(res.Data[0]) == 0 {
+		return false, ErrBadResult
+	}
+	ro, ok := res.Data[0][0].(bool)
+	if !ok {
+		return false, ErrBadResult
+	}
+	return ro, nil
+}
+
+// Refresh re-detects roles of all instances synchronously.
+func (p *Pool) Refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, inst := range p.instances {
+		wg.Add(1)
+		go func(inst *poolInstance) {
+			defer wg.Done()
+			atomic.StoreInt32(&inst.role, p.detectRole(ctx, inst))
+		}(inst)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) detectRole(ctx context.Context, inst *poolInstance) int32 {
+	conn, err := inst.connector.ConnectContext(ctx)
+	if err != nil {
+		return roleUnknown
+	}
+	ro, err := p.opts.RoleCheck(ctx, conn)
+	if err != nil {
+		return roleUnknown
+	}
+	if ro {
+		return roleReplica
+	}
+	return roleMaster
+}
+
+// checker re-detects roles periodically or on demand, e.g. after a failover has been noticed.
+func (p *Pool) checker() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.exit:
+			return
+		case <-ticker.C:
+		case <-p.refresh:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.opts.CheckInterval)
+		go func() {
+			select {
+			case <-p.exit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		p.Refresh(ctx)
+		cancel()
+	}
+}
+
+func (p *Pool) scheduleRefresh() {
+	select {
+	case p.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// pick returns an instance suitable for the mode or nil.
+func (p *Pool) pick(mode PoolMode) *poolInstance {
+	var candidates []*poolInstance
+
+	collect := func(roles ...int32) {
+		for _, inst := range p.instances {
+			role := atomic.LoadInt32(&inst.role)
+			for _, r := range roles {
+				if role == r {
+					candidates = append(candidates, inst)
+				}
+			}
+		}
+	}
+
+	switch mode {
+	case ModeRW:
+		collect(roleMaster)
+	case ModeRO:
+		collect(roleReplica)
+	case ModePreferRO:
+		if collect(roleReplica); len(candidates) == 0 {
+			collect(roleMaster)
+		}
+	default:
+		collect(roleMaster, roleReplica)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		return candidates[0]
+	}
+
+	if p.opts.Balancer == BalanceLeastInflight {
+		best := candidates[0]
+		for _, inst := range candidates[1:] {
+			if atomic.LoadInt64(&inst.inflight) < atomic.LoadInt64(&best.inflight) {
+				best = inst
+			}
+		}
+		return best
+	}
+
+	n := atomic.AddUint64(&p.rr, 1)
+	return candidates[n%uint64(len(candidates))]
+}
+
+// QueryMode returns the default routing mode for the query:
+// data modifications require the master, selects prefer replicas.
+func QueryMode(q Query) PoolMode {
+	switch q.(type) {
+	case *Select:
+		return ModePreferRO
+	case *Ping:
+		return ModeAny
+	default:
+		return ModeRW
+	}
+}
+
+// Exec routes the query according to QueryMode.
+func (p *Pool) Exec(ctx context.Context, q Query, options ...ExecOption) *Result {
+	return p.ExecMode(ctx, QueryMode(q), q, options...)
+}
+
+// ExecMode executes the query on an instance chosen by mode.
+func (p *Pool) ExecMode(ctx context.Context, mode PoolMode, q Query, options ...ExecOption) *Result {
+	inst := p.pick(mode)
+	if inst == nil {
+		p.scheduleRefresh()
+		return &Result{
+			Error:     ErrNoPoolInstance,
+			ErrorCode: ErrNoConnection,
+		}
+	}
+
+	conn, err := inst.connector.ConnectContext(ctx)
+	if err != nil {
+		atomic.StoreInt32(&inst.role, roleUnknown)
+		p.scheduleRefresh()
+		return &Result{
+			Error:     err,
+			ErrorCode: ErrNoConnection,
+		}
+	}
+
+	atomic.AddInt64(&inst.inflight, 1)
+	res := conn.Exec(ctx, q, options...)
+	atomic.AddInt64(&inst.inflight, -1)
+
+	// the master has been switched to read-only or has gone: roles must be re-detected
+	if res.ErrorCode == ErrReadonly || res.ErrorCode == ErrNoConnection {
+		p.scheduleRefresh()
+	}
+	return res
+}
+
+// Master returns the connection to the current master.
+func (p *Pool) Master(ctx context.Context) (*Connection, error) {
+	inst := p.pick(ModeRW)
+	if inst == nil {
+		return nil, ErrNoPoolInstance
+	}
+	return inst.connector.ConnectContext(ctx)
+}
+
+// Close stops role detection and closes all connections.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.exit)
+		p.wg.Wait()
+		for _, inst := range p.instances {
+			inst.connector.Close()
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package tarantool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPoolTestInstance(t *testing.T, name string, ro *int32) *iprotoListener {
+	return newIprotoListener(t, func(ctx context.Context, q Query) *Result {
+		switch q.(type) {
+		case *Eval:
+			return &Result{Data: [][]interface{}{{atomic.LoadInt32(ro) == 1}}}
+		case *Select:
+			if q.(*Select).Space == ViewSpace || q.(*Select).Space == ViewIndex {
+				return &Result{}
+			}
+		}
+		return &Result{Data: [][]interface{}{{name}}}
+	})
+}
+
+func TestPoolRouting(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	masterRO, replicaRO := int32(0), int32(1)
+	master := newPoolTestInstance(t, "master", &masterRO)
+	defer master.Close()
+	replica := newPoolTestInstance(t, "replica", &replicaRO)
+	defer replica.Close()
+
+	pool, err := NewPool([]string{master.Addr(), replica.Addr()}, &PoolOptions{
+		CheckInterval: time.Hour,
+	})
+	require.NoError(err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		res := pool.Exec(ctx, &Insert{Space: 1, Tuple: []interface{}{1}})
+		require.NoError(res.Error)
+		assert.Equal("master", res.Data[0][0])
+
+		res = pool.Exec(ctx, &Select{Space: 1})
+		require.NoError(res.Error)
+		assert.Equal("replica", res.Data[0][0])
+	}
+
+	res := pool.ExecMode(ctx, ModeRO, &Call17{Name: "f"})
+	require.NoError(res.Error)
+	assert.Equal("replica", res.Data[0][0])
+
+	// failover: the replica is promoted, the master becomes read-only
+	atomic.StoreInt32(&masterRO, 1)
+	atomic.StoreInt32(&replicaRO, 0)
+	pool.Refresh(ctx)
+
+	res = pool.Exec(ctx, &Update{Space: 1, Key: 1})
+	require.NoError(res.Error)
+	assert.Equal("replica", res.Data[0][0])
+
+	res = pool.Exec(ctx, &Select{Space: 1})
+	require.NoError(res.Error)
+	assert.Equal("master", res.Data[0][0])
+}
+
+func TestPoolNoMaster(t *testing.T) {
+	require := require.New(t)
+
+	replicaRO := int32(1)
+	replica := newPoolTestInstance(t, "replica", &replicaRO)
+	defer replica.Close()
+
+	pool, err := NewPool([]string{replica.Addr()}, &PoolOptions{
+		Balancer:      BalanceLeastInflight,
+		CheckInterval: time.Hour,
+	})
+	require.NoError(err)
+	defer pool.Close()
+
+	res := pool.Exec(context.Background(), &Delete{Space: 1, Key: 1})
+	require.Equal(ErrNoPoolInstance, res.Error)
+
+	res = pool.Exec(context.Background(), &Select{Space: 1})
+	require.NoError(res.Error)
+	require.Equal("replica", res.Data[0][0])
+}
@@ -0,0 +1,108 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PositionStore keeps the replication position of Slave between restarts.
+// See Slave.SetPositionStore and Slave.Resume.
+type PositionStore interface {
+	// Load returns the stored vector clock or nil if nothing has been stored yet.
+	Load() (VectorClock, error)
+	// Save stores the vector clock replacing the previous one.
+	Save(vc VectorClock) error
+}
+
+// MemoryPositionStore keeps the position in memory, e.g. to resume replication
+// with a new Slave within the same process. The zero value is ready to use.
+type MemoryPositionStore struct {
+	mu sync.Mutex
+	vc VectorClock
+}
+
+var _ PositionStore = (*MemoryPositionStore)(nil)
+
+// Load implements PositionStore.
+func (m *MemoryPositionStore) Load() (VectorClock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.vc == nil {
+		return nil, nil
+	}
+	return m.vc.Clone(), nil
+}
+
+// Save implements PositionStore.
+func (m *MemoryPositionStore) Save(vc VectorClock) error {
+	m.mu.Lock()
+	m.vc = vc.Clone()
+	m.mu.Unlock()
+	return nil
+}
+
+// FilePositionStore keeps the position in a file as JSON array of the clocks.
+// The file is replaced atomically, so it holds either the previous or the new position after a crash.
+type FilePositionStore struct {
+	Path string
+}
+
+var _ PositionStore = (*FilePositionStore)(nil)
+
+// NewFilePositionStore returns FilePositionStore keeping the position in the file at path.
+func NewFilePositionStore(path string) *FilePositionStore {
+	return &FilePositionStore{Path: path}
+}
+
+// Load implements PositionStore. It returns nil vector clock if the file doesn't exist.
+func (f *FilePositionStore) Load() (VectorClock, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lsns []uint64
+	if err = json.Unmarshal(data, &lsns); err != nil {
+		return nil, err
+	}
+	if len(lsns) >= VClockMax {
+		return nil, ErrVectorClock
+	}
+
+	// keep the capacity expected by VectorClock.Follow
+	vc := make(VectorClock, len(lsns), VClockMax)
+	copy(vc, lsns)
+	return vc, nil
+}
+
+// Save implements PositionStore. The position is written to a temporary file
+// in the same directory, which is synced and then renamed to Path.
+func (f *FilePositionStore) Save(vc VectorClock) error {
+	data, err := json.Marshal([]uint64(vc.Clone()))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
@@ -0,0 +1,158 @@
+package tarantool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionStore(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, store := range []PositionStore{
+		&MemoryPositionStore{},
+		NewFilePositionStore(filepath.Join(dir, "position.json")),
+	} {
+		name := fmt.Sprintf("%T", store)
+
+		vc, err := store.Load()
+		require.NoError(t, err, name)
+		assert.Nil(t, vc, name)
+
+		saved := NewVectorClock(10, 20)
+		require.NoError(t, store.Save(saved), name)
+		saved.Follow(1, 11)
+		require.NoError(t, store.Save(saved), name)
+		saved.Follow(3, 5)
+
+		vc, err = store.Load()
+		require.NoError(t, err, name)
+		assert.Equal(t, VectorClock{0, 11, 20}, vc, name)
+
+		// the loaded vclock can be followed as any other
+		assert.True(t, vc.Follow(4, 1), name)
+	}
+
+	// no temporary files are left
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0644))
+	_, err = NewFilePositionStore(filepath.Join(dir, "bad.json")).Load()
+	assert.Error(t, err)
+}
+
+func TestSlaveCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	store := &MemoryPositionStore{}
+	s := &Slave{VClock: NewVectorClock(1)}
+
+	// receive emulates the packets returned by nextXlog
+	receive := func(n int) {
+		for i := 0; i < n; i++ {
+			s.VClock.Follow(1, s.VClock[1]+1)
+			s.unsaved++
+		}
+	}
+	saved := func() VectorClock {
+		vc, err := store.Load()
+		assert.NoError(err)
+		return vc
+	}
+
+	s.SetPositionStore(store, 3, 0)
+	receive(2)
+	assert.NoError(s.checkpoint())
+	assert.Nil(saved())
+	receive(1)
+	assert.NoError(s.checkpoint())
+	assert.Equal(VectorClock{0, 4}, saved())
+
+	s.SetPositionStore(store, 0, 50*time.Millisecond)
+	receive(1)
+	assert.NoError(s.checkpoint())
+	assert.Equal(VectorClock{0, 4}, saved())
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(s.checkpoint())
+	assert.Equal(VectorClock{0, 5}, saved())
+
+	s.SetPositionStore(store, 0, 0)
+	receive(1)
+	assert.NoError(s.checkpoint())
+	assert.Equal(VectorClock{0, 6}, saved())
+
+	s.SetPositionStore(nil, 0, 0)
+	assert.Equal(ErrNoPositionStore, s.SavePosition())
+	_, err := s.Resume()
+	assert.Equal(ErrNoPositionStore, err)
+
+	assert.True(isPositionLost(NewQueryError(ErrXlogGap, "Missing .xlog file")))
+	assert.False(isPositionLost(NewQueryError(ErrAccessDenied, "Read access denied")))
+}
+
+func TestSlaveResumeAfterXlogGap(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	m := newTestMaster(t, true)
+	defer m.l.Close()
+
+	s, err := NewSlave(m.l.Addr().String(), Options{UUID: randomUUID(), ReplicaSetUUID: randomUUID()})
+	require.NoError(err)
+	defer s.Close()
+
+	store := &MemoryPositionStore{}
+	require.NoError(store.Save(NewVectorClock(3)))
+	s.SetPositionStore(store, 0, 0)
+
+	it, err := s.Resume()
+	require.NoError(err)
+
+	// the master has no xlogs since LSN 3, so the replica joins on the same connection
+	// and subscribes since the final row of the join
+	done := make(chan []interface{})
+	go func() {
+		var tuples []interface{}
+		for {
+			p, err := it.Next()
+			if err != nil {
+				break
+			}
+			if q, ok := p.Request.(*Insert); ok {
+				tuples = append(tuples, q.Tuple[0])
+				if p.LSN == 7 {
+					break
+				}
+			}
+		}
+		done <- tuples
+	}()
+
+	var sub testSubscribe
+	select {
+	case sub = <-m.subscribes:
+	case <-time.After(time.Second):
+		t.Fatal("no subscribe")
+	}
+	assert.Equal(VectorClock{0, 6}, sub.vclock)
+
+	writeTestInsert(sub.conn, 7, time.Now())
+	select {
+	case tuples := <-done:
+		assert.Equal([]interface{}{int64(1), int64(6), int64(7)}, tuples)
+	case <-time.After(time.Second):
+		t.Fatal("no packets")
+	}
+
+	saved, err := store.Load()
+	require.NoError(err)
+	assert.Equal(VectorClock{0, 6}, saved)
+	assert.Equal(VectorClock{0, 7}, s.VClock)
+}
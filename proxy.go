@@ -0,0 +1,393 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrAuthNotForwarded is returned to chap-sha1 Auth requests if the proxy has no Credentials:
+// the scramble is made with the salt of the proxy, so it can't be checked upstream.
+var ErrAuthNotForwarded = errors.New("chap-sha1 auth can't be forwarded, use pap-sha256 or proxy Credentials")
+
+// ErrAuthNotMapped is returned to Auth requests if the proxy has MapCredentials but no Credentials:
+// only the mapped credentials would be checked upstream, so any password would be accepted.
+var ErrAuthNotMapped = errors.New("auth can't be forwarded with mapped credentials, use proxy Credentials")
+
+// ProxyOptions configures Proxy. The sessions are served as by Server,
+// so the embedded options apply to them, e.g. TLSConfig or MaxConnections.
+type ProxyOptions struct {
+	ServerOptions
+	// Route picks the upstream for the request, the first one is used if Route is nil or returns nil.
+	// See ReadWriteSplit.
+	Route func(ctx context.Context, q Query) *Connector
+	// Rewrite replaces the request before it is forwarded, e.g. to rename a function.
+	Rewrite func(ctx context.Context, q Query) Query
+	// Shadow returns the upstreams getting a copy of the request in background,
+	// e.g. a new cluster tested with real traffic. Their results are discarded
+	// after OnShadowResult is called with the result returned to the client.
+	Shadow         func(ctx context.Context, q Query) []*Connector
+	OnShadowResult func(q Query, result, shadow *Result)
+	// MapCredentials maps the user authenticated by the proxy and its password
+	// to the credentials used upstream. The credentials are forwarded as is if it is nil.
+	// It requires ServerOptions.Credentials, otherwise Auth requests get ErrAuthNotMapped.
+	MapCredentials func(user, password string) (upstreamUser, upstreamPassword string)
+}
+
+// Proxy serves sessions as Server does and forwards their requests to upstream Connectors.
+// The responses are sent with the sync ids of the client requests,
+// upstream errors are passed through with their codes.
+//
+// Every stream of a session is mapped to a stream of the upstream picked for its first request.
+// The requests of a stream are forwarded one by one in the order they are received,
+// so the transactions run as they would with a direct connection. The requests of the streams
+// are not shadowed, and the transactions left open are rolled back when the session is closed.
+//
+// Guest sessions use the upstream Connectors as is. Authenticated sessions use
+// connections to the same upstreams made with the credentials of the user, see MapCredentials.
+// If ServerOptions.Credentials is set, the proxy authenticates the sessions itself.
+// Otherwise Auth is forwarded: the upstream is connected with the password of pap-sha256 request,
+// chap-sha1 requests get ErrAuthNotForwarded. MapCredentials can't be used then: Auth gets ErrAuthNotMapped.
+type Proxy struct {
+	server    *Server
+	upstreams []*Connector
+	opts      ProxyOptions
+
+	mu        sync.Mutex
+	passwords map[string]string
+	userConns map[proxyUserConn]*proxyConnector
+	streams   map[*IprotoServer]map[uint64]*proxyStream
+}
+
+// proxyStream is the upstream stream of a client stream.
+type proxyStream struct {
+	*Stream
+	release func()
+}
+
+type proxyUserConn struct {
+	upstream *Connector
+	user     string
+}
+
+// proxyConnector is the upstream Connector made for the user. Once the password of the user
+// has changed, it is replaced and closed after the requests using it are done.
+type proxyConnector struct {
+	*Connector
+	refs    int
+	retired bool
+}
+
+// NewProxy returns Proxy forwarding the requests to the upstreams, at least one is required.
+func NewProxy(upstreams []*Connector, opts *ProxyOptions) *Proxy {
+	p := &Proxy{
+		upstreams: upstreams,
+		passwords: make(map[string]string),
+		userConns: make(map[proxyUserConn]*proxyConnector),
+		streams:   make(map[*IprotoServer]map[uint64]*proxyStream),
+	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	p.server = NewServer(randomUUID(), p.Forward, &p.opts.ServerOptions)
+	return p
+}
+
+// Serve accepts the sessions on the listener until Shutdown or Close is called,
+// then it returns ErrServerClosed.
+func (p *Proxy) Serve(l net.Listener) error {
+	return p.server.Serve(l)
+}
+
+// Sessions returns the number of the sessions being served.
+func (p *Proxy) Sessions() int {
+	return p.server.Sessions()
+}
+
+// Forward implements QueryHandler, it forwards the request to the upstream.
+// It lets the proxy be served by IprotoServer or be wrapped with ServeMux middleware.
+func (p *Proxy) Forward(ctx context.Context, q Query) *Result {
+	if auth, ok := q.(*Auth); ok {
+		return p.forwardAuth(ctx, auth)
+	}
+
+	if p.opts.Rewrite != nil {
+		q = p.opts.Rewrite(ctx, q)
+	}
+
+	var upstream *Connector
+	if p.opts.Route != nil {
+		upstream = p.opts.Route(ctx, q)
+	}
+	if upstream == nil {
+		upstream = p.upstreams[0]
+	}
+
+	user, _ := UserFromContext(ctx)
+	if id, ok := StreamIDFromContext(ctx); ok && id != 0 {
+		return p.execStream(ctx, upstream, user, id, q)
+	}
+	result := p.exec(ctx, upstream, user, q)
+
+	if p.opts.Shadow != nil {
+		for _, shadow := range p.opts.Shadow(ctx, q) {
+			go p.shadow(shadow, user, q, result)
+		}
+	}
+	return result
+}
+
+func (p *Proxy) exec(ctx context.Context, upstream *Connector, user string, q Query) *Result {
+	c, release, err := p.acquire(upstream, user)
+	if err != nil {
+		return errorResult(err, ErrNoConnection)
+	}
+	defer release()
+
+	conn, err := c.ConnectContext(ctx)
+	if err != nil {
+		return errorResult(err, ErrNoConnection)
+	}
+
+	// the data is passed as is, e.g. the values returned by Call17 are not wrapped into tuples
+	return conn.Exec(ctx, q, ExecResultAsRawData)
+}
+
+// execStream executes the request in the upstream stream of the client stream.
+func (p *Proxy) execStream(ctx context.Context, upstream *Connector, user string, id uint64, q Query) *Result {
+	session, _ := ctx.Value(sessionContextKey{}).(*IprotoServer)
+
+	p.mu.Lock()
+	stream, ok := p.streams[session][id]
+	p.mu.Unlock()
+
+	if !ok {
+		var err error
+		if stream, err = p.openStream(ctx, session, upstream, user, id); err != nil {
+			return errorResult(err, ErrNoConnection)
+		}
+	}
+	return stream.Exec(ctx, q, ExecResultAsRawData)
+}
+
+func (p *Proxy) openStream(ctx context.Context, session *IprotoServer, upstream *Connector, user string, id uint64) (*proxyStream, error) {
+	c, release, err := p.acquire(upstream, user)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.ConnectContext(ctx)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	stream := &proxyStream{Stream: conn.NewStream(), release: release}
+
+	p.mu.Lock()
+	streams, ok := p.streams[session]
+	if !ok {
+		streams = make(map[uint64]*proxyStream)
+		p.streams[session] = streams
+		if done := ctx.Done(); done != nil {
+			go func() {
+				<-done
+				p.closeStreams(session)
+			}()
+		}
+	}
+	if existing, ok := streams[id]; ok {
+		p.mu.Unlock()
+		release()
+		return existing, nil
+	}
+	streams[id] = stream
+	p.mu.Unlock()
+
+	return stream, nil
+}
+
+// closeStreams rolls back the transactions of the closed session.
+func (p *Proxy) closeStreams(session *IprotoServer) {
+	p.mu.Lock()
+	streams := p.streams[session]
+	delete(p.streams, session)
+	p.mu.Unlock()
+
+	for _, stream := range streams {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeout)
+		stream.Rollback(ctx)
+		cancel()
+		stream.release()
+	}
+}
+
+func (p *Proxy) shadow(upstream *Connector, user string, q Query, result *Result) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeout)
+	defer cancel()
+
+	shadow := p.exec(ctx, upstream, user, q)
+	if p.opts.OnShadowResult != nil {
+		p.opts.OnShadowResult(q, result, shadow)
+	}
+}
+
+// acquire returns the upstream Connector with the credentials of the user,
+// release must be called once it is not used anymore.
+func (p *Proxy) acquire(upstream *Connector, user string) (*Connector, func(), error) {
+	if user == "" || user == GuestUser {
+		return upstream, func() {}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	password, ok := p.passwords[user]
+	if !ok && p.opts.Credentials != nil {
+		password, ok = p.opts.Credentials.Password(user)
+	}
+	if !ok {
+		return nil, nil, NewQueryError(ErrAccessDenied, fmt.Sprintf("Session access denied for user '%s'", user))
+	}
+
+	key := proxyUserConn{upstream, user}
+	pc, exists := p.userConns[key]
+	if !exists {
+		c, err := p.newUserConnector(upstream, user, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		pc = &proxyConnector{Connector: c}
+		p.userConns[key] = pc
+	}
+	pc.refs++
+	return pc.Connector, func() { p.release(pc) }, nil
+}
+
+func (p *Proxy) release(pc *proxyConnector) {
+	p.mu.Lock()
+	pc.refs--
+	unused := pc.retired && pc.refs == 0
+	p.mu.Unlock()
+
+	if unused {
+		pc.Close()
+	}
+}
+
+// retire closes the connector once it is unused, it must be called with the lock held.
+func (p *Proxy) retire(pc *proxyConnector) {
+	pc.retired = true
+	if pc.refs == 0 {
+		go pc.Close()
+	}
+}
+
+// newUserConnector returns Connector to the address of the upstream, e.g. with its tls scheme,
+// made with the credentials of the user.
+func (p *Proxy) newUserConnector(upstream *Connector, user, password string) (*Connector, error) {
+	upstream.Lock()
+	err := upstream.resolve()
+	scheme, addr, opts := upstream.scheme, upstream.RemoteAddr, upstream.options
+	upstream.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	opts.User, opts.Password = user, password
+	if p.opts.MapCredentials != nil {
+		opts.User, opts.Password = p.opts.MapCredentials(user, password)
+	}
+	return &Connector{RemoteAddr: addr, scheme: scheme, options: opts}, nil
+}
+
+// forwardAuth checks the credentials by connecting the first upstream with them.
+// Sessions send the password in plain text over TLS only, see IprotoServer.VerifyAuth.
+func (p *Proxy) forwardAuth(ctx context.Context, auth *Auth) *Result {
+	method := auth.Method
+	if method == "" {
+		method = AuthChapSha1
+	}
+	if method != AuthPapSha256 {
+		return errorResult(ErrAuthNotForwarded, ErrUnsupported)
+	}
+	if p.opts.MapCredentials != nil {
+		return errorResult(ErrAuthNotMapped, ErrUnsupported)
+	}
+
+	password := string(auth.GreetingAuth)
+	key := proxyUserConn{p.upstreams[0], auth.User}
+
+	// the credentials have been checked already
+	p.mu.Lock()
+	pc, exists := p.userConns[key]
+	if exists && p.passwords[auth.User] == password && !pc.retired {
+		p.mu.Unlock()
+		return &Result{}
+	}
+	p.mu.Unlock()
+
+	c, err := p.newUserConnector(p.upstreams[0], auth.User, password)
+	if err != nil {
+		return errorResult(err, ErrNoConnection)
+	}
+
+	if _, err := c.ConnectContext(ctx); err != nil {
+		c.Close()
+		return errorResult(err, ErrCredsMismatch)
+	}
+
+	p.mu.Lock()
+	// the requests of the other sessions of the user are not interrupted
+	for k, pc := range p.userConns {
+		if k.user == auth.User {
+			p.retire(pc)
+			delete(p.userConns, k)
+		}
+	}
+	p.passwords[auth.User] = password
+	p.userConns[key] = &proxyConnector{Connector: c}
+	p.mu.Unlock()
+
+	return &Result{}
+}
+
+// Shutdown stops the proxy gracefully as Server does and closes the upstream connections made for the users.
+// The upstream Connectors passed to NewProxy are left open.
+func (p *Proxy) Shutdown(ctx context.Context) error {
+	err := p.server.Shutdown(ctx)
+	p.closeUserConns()
+	return err
+}
+
+// Close stops the proxy immediately and closes the upstream connections made for the users.
+func (p *Proxy) Close() error {
+	err := p.server.Close()
+	p.closeUserConns()
+	return err
+}
+
+func (p *Proxy) closeUserConns() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, c := range p.userConns {
+		c.Close()
+		delete(p.userConns, key)
+	}
+}
+
+// ReadWriteSplit returns Route for ProxyOptions sending Select requests to the replicas in turn
+// and other requests to the primary.
+func ReadWriteSplit(primary *Connector, replicas ...*Connector) func(ctx context.Context, q Query) *Connector {
+	var next uint32
+
+	return func(_ context.Context, q Query) *Connector {
+		if _, ok := q.(*Select); !ok || len(replicas) == 0 {
+			return primary
+		}
+		i := atomic.AddUint32(&next, 1)
+		return replicas[int(i-1)%len(replicas)]
+	}
+}
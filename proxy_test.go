@@ -0,0 +1,464 @@
+package tarantool
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProxy(t *testing.T, upstreams []*Connector, opts *ProxyOptions) (*Proxy, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := NewProxy(upstreams, opts)
+	go p.Serve(l)
+	return p, l.Addr().String()
+}
+
+func newTestProxyEmulator(t *testing.T, name string) (*Emulator, *Connector) {
+	emu := NewEmulator()
+	require.NoError(t, emu.CreateSpace(512, "tester", nil, EmulatorIndex{Name: "primary", Unique: true, Parts: []uint{0}}))
+	emu.RegisterFunc("whoami", func(context.Context, []interface{}) ([]interface{}, error) {
+		return []interface{}{name, int64(1)}, nil
+	})
+
+	addr, err := emu.Start("127.0.0.1:0")
+	require.NoError(t, err)
+	return emu, New(addr, nil)
+}
+
+func TestProxyForward(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	primary, primaryConn := newTestProxyEmulator(t, "primary")
+	defer primary.Close()
+	defer primaryConn.Close()
+	replica, replicaConn := newTestProxyEmulator(t, "replica")
+	defer replica.Close()
+	defer replicaConn.Close()
+	shadow, shadowConn := newTestProxyEmulator(t, "shadow")
+	defer shadow.Close()
+	defer shadowConn.Close()
+
+	shadowed := make(chan [2]*Result, 16)
+
+	p, addr := newTestProxy(t, []*Connector{primaryConn}, &ProxyOptions{
+		Route: ReadWriteSplit(primaryConn, replicaConn),
+		Rewrite: func(_ context.Context, q Query) Query {
+			if call, ok := q.(*Call17); ok && call.Name == "me" {
+				return &Call17{Name: "whoami", Tuple: call.Tuple}
+			}
+			return q
+		},
+		Shadow: func(_ context.Context, q Query) []*Connector {
+			if _, ok := q.(*Insert); ok {
+				return []*Connector{shadowConn}
+			}
+			return nil
+		},
+		OnShadowResult: func(_ Query, result, shadow *Result) {
+			shadowed <- [2]*Result{result, shadow}
+		},
+	})
+	defer p.Close()
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	// the schema of the primary is known through the proxy
+	_, ok := conn.GetPrimaryKeyFields("tester")
+	assert.True(ok)
+
+	res := conn.Exec(context.Background(), &Insert{Space: "tester", Tuple: []interface{}{uint64(1), "one"}})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{int64(1), "one"}}, res.Data)
+
+	select {
+	case results := <-shadowed:
+		require.NoError(results[1].Error)
+		assert.Equal(results[0].RawData, results[1].RawData)
+	case <-time.After(time.Second):
+		t.Fatal("no shadow result")
+	}
+
+	// the error is passed with its code
+	res = conn.Exec(context.Background(), &Insert{Space: "tester", Tuple: []interface{}{uint64(1), "one"}})
+	assert.Equal(ErrTupleFound, res.ErrorCode)
+	assert.Contains(res.Error.Error(), "Duplicate key exists")
+
+	res = conn.Exec(context.Background(), &Call17{Name: "missing"})
+	assert.Equal(ErrNoSuchProc, res.ErrorCode)
+
+	// the values returned by Call17 are passed as is
+	res = conn.Exec(context.Background(), &Call17{Name: "me"}, ExecResultAsRawData)
+	require.NoError(res.Error)
+	assert.Equal([]interface{}{"primary", int64(1)}, res.RawData)
+
+	// selects are read from the replica
+	res = conn.Exec(context.Background(), &Select{Space: "tester", Key: uint64(1)})
+	require.NoError(res.Error)
+	assert.Empty(res.Data)
+
+	direct, err := shadowConn.Connect()
+	require.NoError(err)
+	res = direct.Exec(context.Background(), &Select{Space: "tester", Key: uint64(1)})
+	assert.Len(res.Data, 1)
+
+	// pipelined requests get the responses to their own sync ids
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res := conn.Exec(context.Background(), &Replace{Space: "tester", Tuple: []interface{}{uint64(100 + i)}})
+			if assert.NoError(res.Error) {
+				assert.Equal([][]interface{}{{int64(100 + i)}}, res.Data)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestProxyAuth(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	upstream := newIprotoListenerWithOptions(t, func(ctx context.Context, q Query) *Result {
+		if _, ok := q.(*Call17); ok {
+			user, _ := UserFromContext(ctx)
+			return &Result{RawData: []interface{}{user}}
+		}
+		return &Result{}
+	}, &IprotoServerOptions{Credentials: StaticCredentials{"alice": "secret"}})
+	defer upstream.Close()
+
+	upstreamConn := New(upstream.Addr(), nil)
+	defer upstreamConn.Close()
+
+	whoami := func(conn *Connection) interface{} {
+		res := conn.Exec(context.Background(), &Call17{Name: "whoami"}, ExecResultAsRawData)
+		require.NoError(res.Error)
+		return res.RawData
+	}
+
+	// the proxy authenticates the sessions and maps the users
+	p, addr := newTestProxy(t, []*Connector{upstreamConn}, &ProxyOptions{
+		ServerOptions: ServerOptions{IprotoServerOptions: IprotoServerOptions{
+			Credentials: StaticCredentials{"bob": "pass"},
+		}},
+		MapCredentials: func(user, password string) (string, string) {
+			return "alice", "secret"
+		},
+	})
+	defer p.Close()
+
+	conn, err := Connect(addr, &Options{User: "bob", Password: "pass"})
+	require.NoError(err)
+	assert.Equal([]interface{}{"alice"}, whoami(conn))
+	conn.Close()
+
+	conn, err = Connect(addr, nil)
+	require.NoError(err)
+	assert.Equal([]interface{}{GuestUser}, whoami(conn))
+	conn.Close()
+
+	_, err = Connect(addr, &Options{User: "bob", Password: "wrong"})
+	assert.Error(err)
+
+	// pap-sha256 auth is forwarded
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+	p, addr = newTestProxy(t, []*Connector{upstreamConn}, &ProxyOptions{
+		ServerOptions: ServerOptions{IprotoServerOptions: IprotoServerOptions{TLSConfig: serverConfig}},
+	})
+	defer p.Close()
+
+	opts := &Options{User: "alice", Password: "secret", AuthMethod: AuthPapSha256, TLSConfig: clientConfig}
+	conn, err = Connect("tls://"+addr, opts)
+	require.NoError(err)
+	assert.Equal([]interface{}{"alice"}, whoami(conn))
+	conn.Close()
+
+	opts.Password = "wrong"
+	_, err = Connect("tls://"+addr, opts)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "credentials are invalid")
+	}
+
+	_, err = Connect("tls://"+addr, &Options{User: "alice", Password: "secret", TLSConfig: clientConfig})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), ErrAuthNotForwarded.Error())
+	}
+
+	// the password of the client can't be checked if the credentials are mapped
+	p, addr = newTestProxy(t, []*Connector{upstreamConn}, &ProxyOptions{
+		ServerOptions: ServerOptions{IprotoServerOptions: IprotoServerOptions{TLSConfig: serverConfig}},
+		MapCredentials: func(user, password string) (string, string) {
+			return "alice", "secret"
+		},
+	})
+	defer p.Close()
+
+	opts.Password = "wrong"
+	_, err = Connect("tls://"+addr, opts)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), ErrAuthNotMapped.Error())
+	}
+}
+
+type testCredentials struct {
+	sync.Mutex
+	passwords map[string]string
+}
+
+func (c *testCredentials) Password(user string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+	password, ok := c.passwords[user]
+	return password, ok
+}
+
+func (c *testCredentials) set(user, password string) {
+	c.Lock()
+	c.passwords[user] = password
+	c.Unlock()
+}
+
+func TestProxyAuthConnectors(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	started, unblock := make(chan struct{}, 1), make(chan struct{})
+	creds := &testCredentials{passwords: map[string]string{"alice": "secret"}}
+	upstream := newIprotoListenerWithOptions(t, func(ctx context.Context, q Query) *Result {
+		if call, ok := q.(*Call17); ok && call.Name == "slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+		return &Result{}
+	}, &IprotoServerOptions{Credentials: creds})
+	defer upstream.Close()
+
+	upstreamConn := New(upstream.Addr(), nil)
+	defer upstreamConn.Close()
+
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+	p, addr := newTestProxy(t, []*Connector{upstreamConn}, &ProxyOptions{
+		ServerOptions: ServerOptions{IprotoServerOptions: IprotoServerOptions{TLSConfig: serverConfig}},
+	})
+	defer p.Close()
+
+	connect := func(password string) *Connection {
+		conn, err := Connect("tls://"+addr, &Options{User: "alice", Password: password, AuthMethod: AuthPapSha256, TLSConfig: clientConfig})
+		require.NoError(err)
+		return conn
+	}
+	userConn := func() *proxyConnector {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.userConns[proxyUserConn{upstreamConn, "alice"}]
+	}
+
+	conn := connect("secret")
+	defer conn.Close()
+	first := userConn()
+
+	slow := make(chan *Result, 1)
+	go func() {
+		slow <- conn.Exec(context.Background(), &Call17{Name: "slow"})
+	}()
+	<-started
+
+	// the connector is reused while the password is the same
+	same := connect("secret")
+	same.Close()
+	assert.True(first == userConn())
+
+	// the replaced connector is closed once the request is done
+	creds.set("alice", "changed")
+	changed := connect("changed")
+	defer changed.Close()
+	assert.False(first == userConn())
+	assert.NotEqual(StateClosed, first.State())
+
+	close(unblock)
+	require.NoError((<-slow).Error)
+	assert.Eventually(func() bool {
+		return first.State() == StateClosed
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(conn.Exec(context.Background(), &Call17{Name: "whoami"}).Error)
+
+	// the password is not accepted in plain text
+	plain, plainAddr := newTestProxy(t, []*Connector{upstreamConn}, nil)
+	defer plain.Close()
+
+	s := dialTestSession(t, plainAddr)
+	defer s.conn.Close()
+	s.send(t, &Auth{User: "alice", Password: "changed", GreetingAuth: s.salt, Method: AuthPapSha256})
+	res, err := s.receive(time.Second)
+	require.NoError(err)
+	assert.Equal(ErrCredsMismatch, res.Cmd&^ErrorFlag)
+	assert.Empty(plain.userConns)
+}
+
+func TestProxyStreams(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	// the upstream keeps the inserts of the transactions by stream
+	var (
+		mu        sync.Mutex
+		begins    []uint64
+		txs       = make(map[uint64][]interface{})
+		committed []interface{}
+	)
+	upstream := newIprotoListener(t, func(ctx context.Context, q Query) *Result {
+		mu.Lock()
+		defer mu.Unlock()
+
+		id, _ := StreamIDFromContext(ctx)
+		switch q := q.(type) {
+		case *Begin:
+			begins = append(begins, id)
+			txs[id] = []interface{}{}
+		case *Insert:
+			if tx, ok := txs[id]; ok {
+				txs[id] = append(tx, q.Tuple[0])
+			} else {
+				committed = append(committed, q.Tuple[0])
+			}
+		case *Commit:
+			committed = append(committed, txs[id]...)
+			delete(txs, id)
+		case *Rollback:
+			delete(txs, id)
+		case *Call17:
+			return &Result{RawData: committed}
+		}
+		return &Result{}
+	})
+	defer upstream.Close()
+
+	upstreamConn := New(upstream.Addr(), nil)
+	defer upstreamConn.Close()
+
+	p, addr := newTestProxy(t, []*Connector{upstreamConn}, nil)
+	defer p.Close()
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	insert := func(s *Stream, id int64) {
+		require.NoError(s.Exec(ctx, &Insert{Space: 512, Tuple: []interface{}{id}}).Error)
+	}
+	committedData := func() interface{} {
+		res := conn.Exec(ctx, &Call17{Name: "committed"}, ExecResultAsRawData)
+		require.NoError(res.Error)
+		return res.RawData
+	}
+
+	s := conn.NewStream()
+	require.NoError(s.Begin(ctx, TxnIsolationDefault, 0))
+	insert(s, 1)
+	require.NoError(s.Rollback(ctx))
+
+	require.NoError(s.Begin(ctx, TxnIsolationDefault, 0))
+	insert(s, 2)
+	require.NoError(s.Commit(ctx))
+	assert.Equal([]interface{}{int64(2)}, committedData())
+
+	// the streams of the other session with the same ids are not mixed up
+	other, err := Connect(addr, nil)
+	require.NoError(err)
+	otherStream := other.NewStream()
+	assert.Equal(s.ID, otherStream.ID)
+	require.NoError(otherStream.Begin(ctx, TxnIsolationDefault, 0))
+	insert(otherStream, 3)
+
+	mu.Lock()
+	require.Len(begins, 3)
+	assert.NotZero(begins[0])
+	assert.Equal(begins[0], begins[1])
+	assert.NotEqual(begins[0], begins[2])
+	mu.Unlock()
+
+	// the transaction is rolled back once the session is closed
+	other.Close()
+	assert.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(txs) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal([]interface{}{int64(2)}, committedData())
+}
+
+func TestProxyStreamOrder(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		mu      sync.Mutex
+		inserts = make(map[uint64][]interface{})
+	)
+	upstream := newIprotoListener(t, func(ctx context.Context, q Query) *Result {
+		// the handlers of the requests run concurrently unless they are of the same stream
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+
+		id, _ := StreamIDFromContext(ctx)
+		if q, ok := q.(*Insert); ok {
+			mu.Lock()
+			inserts[id] = append(inserts[id], q.Tuple[0])
+			mu.Unlock()
+		}
+		return &Result{}
+	})
+	defer upstream.Close()
+
+	upstreamConn := New(upstream.Addr(), nil)
+	defer upstreamConn.Close()
+
+	p, addr := newTestProxy(t, []*Connector{upstreamConn}, nil)
+	defer p.Close()
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	// the requests are pipelined without waiting for the responses
+	const n = 100
+	streams := []*Stream{conn.NewStream(), conn.NewStream()}
+	replies := make(chan *AsyncResult, n*len(streams))
+	expected := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		expected[i] = int64(i)
+		for _, s := range streams {
+			q := &Insert{Space: 512, Tuple: []interface{}{int64(i)}}
+			require.NoError(conn.ExecAsync(context.Background(), q, nil, replies, StreamExecOption(s.ID)))
+		}
+	}
+	for i := 0; i < n*len(streams); i++ {
+		select {
+		case ar := <-replies:
+			require.NoError(ar.Error)
+			ar.BinaryPacket.Release()
+		case <-time.After(5 * time.Second):
+			require.FailNow("no reply")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(inserts, len(streams))
+	for id, values := range inserts {
+		require.NotZero(id)
+		require.Equal(expected, values)
+	}
+}
@@ -30,8 +30,26 @@ func NewQuery(cmd uint) Query {
 		return &Upsert{}
 	case PingCommand:
 		return &Ping{}
+	case IDCommand:
+		return &ID{}
+	case WatchCommand:
+		return &Watch{}
+	case UnwatchCommand:
+		return &Unwatch{}
+	case EventCommand:
+		return &Event{}
 	case EvalCommand:
 		return &Eval{}
+	case ExecuteCommand:
+		return &Execute{}
+	case PrepareCommand:
+		return &Prepare{}
+	case BeginCommand:
+		return &Begin{}
+	case CommitCommand:
+		return &Commit{}
+	case RollbackCommand:
+		return &Rollback{}
 	default:
 		return nil
 	}
@@ -0,0 +1,292 @@
+package tarantool
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultReplicationIdleTimeout is the time ReplicationConsumer waits for a packet from the master,
+// including heartbeats, before the connection is considered broken.
+var DefaultReplicationIdleTimeout = 30 * time.Second
+
+// ReplicationConsumerOptions configures ReplicationConsumer.
+type ReplicationConsumerOptions struct {
+	// Options are used to connect the master. ReconnectDelay and MaxReconnectDelay set the backoff
+	// of reconnection, MaxReconnects limits the consecutive failed attempts, and Perf gets the replication lag.
+	Options
+	// Anon makes the consumer subscribe as an anonymous replica, see AnonSlave.
+	Anon bool
+	// Store keeps the position between restarts, it is saved every SavePackets packets or SaveInterval,
+	// see Slave.SetPositionStore. If it is nil, the consumer starts with a full join and keeps the position in memory.
+	Store        PositionStore
+	SavePackets  int
+	SaveInterval time.Duration
+	// Filter selects the rows passed to the channel, see Slave.SetFilter.
+	Filter *ReplicationFilter
+	// IdleTimeout is the time to wait for a packet from the master, DefaultReplicationIdleTimeout by default.
+	IdleTimeout time.Duration
+	// OnReconnect is called with the error which has broken the replication before every reconnection attempt.
+	OnReconnect func(attempt int, err error)
+}
+
+// ReplicationConsumer receives DML requests from the master with Slave or AnonSlave and keeps the stream going.
+// It starts with Resume, and once the connection is lost, it reconnects with exponential backoff
+// and subscribes since the VClock of the packets delivered. The rows with LSN already seen are skipped by Slave.
+type ReplicationConsumer struct {
+	uri   string
+	opts  ReplicationConsumerOptions
+	store *consumerStore
+	out   chan *Packet
+	done  chan struct{}
+
+	mu     sync.Mutex
+	slave  *Slave
+	closed bool
+	err    error
+}
+
+// NewReplicationConsumer starts consuming the replication stream of the master at uri.
+// The packets are available through the channel returned by Packets.
+func NewReplicationConsumer(uri string, opts *ReplicationConsumerOptions) (*ReplicationConsumer, error) {
+	c := &ReplicationConsumer{
+		uri:  uri,
+		out:  make(chan *Packet),
+		done: make(chan struct{}),
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+
+	// validate the uri and set the default reconnection delays
+	_, options, err := parseOptions(uri, c.opts.Options)
+	if err != nil {
+		return nil, err
+	}
+	c.opts.ReconnectDelay, c.opts.MaxReconnectDelay = options.ReconnectDelay, options.MaxReconnectDelay
+	if c.opts.IdleTimeout == 0 {
+		c.opts.IdleTimeout = DefaultReplicationIdleTimeout
+	}
+
+	store := c.opts.Store
+	if store == nil {
+		store = &MemoryPositionStore{}
+	}
+	c.store = &consumerStore{PositionStore: store}
+
+	go c.run()
+	return c, nil
+}
+
+// Packets returns the channel of the packets received, it is closed after Close
+// or once MaxReconnects attempts have failed, see Err.
+func (c *ReplicationConsumer) Packets() <-chan *Packet {
+	return c.out
+}
+
+// Err returns the error which has stopped the consumer before Close was called.
+func (c *ReplicationConsumer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// VClock returns the vector clock of the packets delivered since subscribe.
+func (c *ReplicationConsumer) VClock() VectorClock {
+	vc, _ := c.store.current()
+	return vc
+}
+
+// Close stops the consumer and closes the connection to the master.
+func (c *ReplicationConsumer) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	s := c.slave
+	c.mu.Unlock()
+
+	if s != nil {
+		return s.Close()
+	}
+	return nil
+}
+
+func (c *ReplicationConsumer) run() {
+	defer close(c.out)
+
+	delay := c.opts.ReconnectDelay
+	for attempt := 1; ; attempt++ {
+		delivered, err := c.consume()
+
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+
+		if delivered {
+			attempt, delay = 1, c.opts.ReconnectDelay
+		}
+		if c.opts.MaxReconnects > 0 && attempt > c.opts.MaxReconnects {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+			return
+		}
+		if c.opts.OnReconnect != nil {
+			c.opts.OnReconnect(attempt, err)
+		}
+
+		// full jitter on the upper half of the current delay as Connector does
+		sleep := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-time.After(sleep):
+		case <-c.done:
+			return
+		}
+
+		if delay *= 2; delay > c.opts.MaxReconnectDelay {
+			delay = c.opts.MaxReconnectDelay
+		}
+	}
+}
+
+// consume connects the master and passes the packets to the channel until the replication is broken.
+// It reports whether any packet has been delivered.
+func (c *ReplicationConsumer) consume() (delivered bool, err error) {
+	s, resume, err := c.connect()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		c.mu.Lock()
+		c.slave = nil
+		c.mu.Unlock()
+		s.Close()
+	}()
+
+	if err = s.SetFilter(c.opts.Filter); err != nil {
+		return false, err
+	}
+	s.SetPositionStore(c.store, c.opts.SavePackets, c.opts.SaveInterval)
+	s.idleTimeout = c.opts.IdleTimeout
+	if _, err = resume(); err != nil {
+		return false, err
+	}
+	if s.subscribed {
+		c.store.start(s.VClock)
+	}
+
+	for s.HasNext() {
+		p := s.Packet()
+
+		// the snapshot rows don't move the position, the rows skipped by the filter do;
+		// the packet is either delivered or the consumer is closed
+		if s.subscribed {
+			c.store.follow(s.VClock)
+		}
+
+		select {
+		case c.out <- p:
+			delivered = true
+		case <-c.done:
+			return delivered, nil
+		}
+	}
+
+	if err = s.Err(); err == nil {
+		err = ErrConnectionClosed
+	}
+	return delivered, err
+}
+
+// connect returns the new Slave or AnonSlave and its Resume.
+func (c *ReplicationConsumer) connect() (*Slave, func(...chan *Packet) (PacketIterator, error), error) {
+	var (
+		s      *Slave
+		resume func(...chan *Packet) (PacketIterator, error)
+	)
+
+	if c.opts.Anon {
+		as, err := NewAnonSlave(c.uri, c.opts.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, resume = &as.Slave, as.Resume
+	} else {
+		slave, err := NewSlave(c.uri, c.opts.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, resume = slave, slave.Resume
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		s.Close()
+		return nil, nil, ErrConnectionClosed
+	}
+	c.slave = s
+	return s, resume, nil
+}
+
+// consumerStore returns the vclock of the packets delivered by the consumer, if any,
+// so it subscribes since them after reconnect, and the stored position otherwise.
+type consumerStore struct {
+	PositionStore
+
+	mu sync.Mutex
+	vc VectorClock
+}
+
+func (cs *consumerStore) Load() (VectorClock, error) {
+	if vc, ok := cs.current(); ok {
+		return vc, nil
+	}
+	return cs.PositionStore.Load()
+}
+
+func (cs *consumerStore) Save(vc VectorClock) error {
+	if err := cs.PositionStore.Save(vc); err != nil {
+		return err
+	}
+
+	// Slave saves the position once the snapshot has been handled and it has subscribed since it
+	cs.start(vc)
+	return nil
+}
+
+// start sets the vclock the consumer has subscribed since unless it has been set before.
+func (cs *consumerStore) start(vc VectorClock) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.vc == nil && len(vc) > 1 {
+		cs.vc = NewVectorClock(vc[1:]...)
+	}
+}
+
+func (cs *consumerStore) current() (VectorClock, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.vc == nil {
+		return nil, false
+	}
+	return cs.vc.Clone(), true
+}
+
+// follow moves the vclock to the one of Slave.
+func (cs *consumerStore) follow(vc VectorClock) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.vc != nil {
+		cs.vc = append(cs.vc[:0], vc...)
+	}
+}
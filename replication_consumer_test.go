@@ -0,0 +1,302 @@
+package tarantool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// testMaster accepts replicas and passes the vclocks they subscribe since with their connections,
+// the test streams the rows to them. The ids of the spaces are selected by their names before subscribe.
+// If xlogGap is set, the first SUBSCRIBE of a connection is accepted and then fails with ErrXlogGap, and JOIN is answered
+// with the snapshot row {1} and the final row {6} of instance 1.
+type testMaster struct {
+	l          net.Listener
+	spaces     map[string]uint64
+	xlogGap    bool
+	subscribes chan testSubscribe
+}
+
+type testSubscribe struct {
+	vclock VectorClock
+	conn   net.Conn
+}
+
+func newTestMaster(t *testing.T, xlogGap bool) *testMaster {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	m := &testMaster{
+		l:          l,
+		spaces:     map[string]uint64{"orders": 513},
+		xlogGap:    xlogGap,
+		subscribes: make(chan testSubscribe, 4),
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m
+}
+
+func (m *testMaster) serve(conn net.Conn) {
+	format := fmt.Sprintf("%%-%ds\n%%-%ds\n", GreetingSize/2-1, GreetingSize/2-1)
+	fmt.Fprintf(conn, format, "Tarantool 2.8.0 (Binary) "+randomUUID(), "c2FsdA==")
+
+	r := bufio.NewReader(conn)
+	pp := &BinaryPacket{}
+	p := &Packet{}
+	var body []byte
+	xlogGap := m.xlogGap
+	for {
+		if _, err := pp.ReadFrom(r); err != nil {
+			conn.Close()
+			return
+		}
+
+		var err error
+		if body, err = p.UnmarshalBinaryHeader(pp.body); err != nil {
+			conn.Close()
+			return
+		}
+		// the subscription is accepted and the relay reports the gap right after
+		if p.Cmd == SubscribeCommand && xlogGap {
+			xlogGap = false
+			writeTestVClock(conn, NewVectorClock(5))
+			resp := msgp.AppendMapHeader(nil, 1)
+			resp = msgp.AppendUint(resp, KeyError)
+			resp = msgp.AppendString(resp, "Missing .xlog file between LSN 3 {1: 3} and 5 {1: 5}")
+			writeTestRow(conn, ErrorFlag|ErrXlogGap, 0, time.Now(), resp)
+			continue
+		}
+		// heartbeat messages of the slave
+		if p.Cmd == OKCommand {
+			continue
+		}
+		if p.Cmd == SubscribeCommand {
+			break
+		}
+		if p.Cmd == JoinCommand {
+			writeTestVClock(conn, NewVectorClock(5))
+			writeTestQuery(conn, 0, time.Now(), &Insert{Space: 512, Tuple: []interface{}{1}})
+			writeTestVClock(conn, NewVectorClock(5))
+			writeTestInsert(conn, 6, time.Now())
+			writeTestVClock(conn, NewVectorClock(6))
+			continue
+		}
+		q := &Select{}
+		if p.Cmd != SelectCommand {
+			conn.Close()
+			return
+		}
+		if _, err = q.UnmarshalMsg(body); err != nil {
+			conn.Close()
+			return
+		}
+
+		resp := msgp.AppendMapHeader(nil, 1)
+		resp = msgp.AppendUint(resp, KeyData)
+		if id, ok := m.spaces[q.Key.(string)]; ok {
+			resp = msgp.AppendArrayHeader(resp, 1)
+			resp = msgp.AppendArrayHeader(resp, 1)
+			resp = msgp.AppendUint64(resp, id)
+		} else {
+			resp = msgp.AppendArrayHeader(resp, 0)
+		}
+		writeTestRow(conn, OKCommand, 0, time.Now(), resp)
+	}
+	vc := NewVectorClock()
+	items, body, _ := msgp.ReadMapHeaderBytes(body)
+	for ; items > 0; items-- {
+		var key uint
+		key, body, _ = msgp.ReadUintBytes(body)
+		if key != KeyVClock {
+			body, _ = msgp.Skip(body)
+			continue
+		}
+		var n uint32
+		n, body, _ = msgp.ReadMapHeaderBytes(body)
+		for ; n > 0; n-- {
+			var id uint32
+			var lsn uint64
+			id, body, _ = msgp.ReadUint32Bytes(body)
+			lsn, body, _ = msgp.ReadUint64Bytes(body)
+			vc.Follow(id, lsn)
+		}
+	}
+
+	// the vclock of the master
+	resp := msgp.AppendMapHeader(nil, 1)
+	resp = msgp.AppendUint(resp, KeyVClock)
+	resp = msgp.AppendMapHeader(resp, 1)
+	resp = msgp.AppendUint(resp, 1)
+	resp = msgp.AppendUint64(resp, 20)
+	writeTestRow(conn, OKCommand, 0, time.Now(), resp)
+
+	m.subscribes <- testSubscribe{vc, conn}
+
+	// skip the heartbeats of the replica
+	for {
+		if _, err := pp.ReadFrom(r); err != nil {
+			return
+		}
+	}
+}
+
+func writeTestRow(conn net.Conn, code uint, lsn uint64, ts time.Time, body []byte) {
+	h := msgp.AppendUint(nil, math.MaxUint32)
+	h = msgp.AppendMapHeader(h, 5)
+	h = msgp.AppendUint(h, KeyCode)
+	h = msgp.AppendUint(h, code)
+	h = msgp.AppendUint(h, KeySync)
+	h = msgp.AppendUint64(h, 0)
+	h = msgp.AppendUint(h, KeyLSN)
+	h = msgp.AppendUint64(h, lsn)
+	h = msgp.AppendUint(h, KeyInstanceID)
+	h = msgp.AppendUint32(h, 1)
+	h = msgp.AppendUint(h, KeyTimestamp)
+	h = msgp.AppendFloat64(h, float64(ts.UnixNano())/1e9)
+	h = append(h, body...)
+	binary.BigEndian.PutUint32(h[1:], uint32(len(h)-5))
+	conn.Write(h)
+}
+
+// writeTestVClock writes the vclock as the master does at the stages of JOIN, i.e. without LSN.
+func writeTestVClock(conn net.Conn, vc VectorClock) {
+	h := msgp.AppendUint(nil, math.MaxUint32)
+	h = msgp.AppendMapHeader(h, 2)
+	h = msgp.AppendUint(h, KeyCode)
+	h = msgp.AppendUint(h, OKCommand)
+	h = msgp.AppendUint(h, KeySync)
+	h = msgp.AppendUint64(h, 0)
+	h = msgp.AppendMapHeader(h, 1)
+	h = msgp.AppendUint(h, KeyVClock)
+	h = msgp.AppendMapHeader(h, uint32(len(vc)-1))
+	for id := 1; id < len(vc); id++ {
+		h = msgp.AppendUint(h, uint(id))
+		h = msgp.AppendUint64(h, vc[id])
+	}
+	binary.BigEndian.PutUint32(h[1:], uint32(len(h)-5))
+	conn.Write(h)
+}
+
+func writeTestInsert(conn net.Conn, lsn uint64, ts time.Time) {
+	writeTestQuery(conn, lsn, ts, &Insert{Space: 512, Tuple: []interface{}{lsn}})
+}
+
+func writeTestQuery(conn net.Conn, lsn uint64, ts time.Time, q Query) {
+	body, _ := q.(internalQuery).packMsg(defaultPackData, nil)
+	writeTestRow(conn, q.GetCommandID(), lsn, ts, body)
+}
+
+func TestReplicationConsumer(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	m := newTestMaster(t, false)
+	defer m.l.Close()
+
+	store := &MemoryPositionStore{}
+	require.NoError(store.Save(NewVectorClock(10)))
+
+	perf := PerfCount{
+		ReplicationLag:    new(expvar.Float),
+		ReplicationLSNLag: new(expvar.Map).Init(),
+	}
+	reconnects := make(chan error, 4)
+
+	c, err := NewReplicationConsumer(m.l.Addr().String(), &ReplicationConsumerOptions{
+		Options: Options{
+			UUID:           randomUUID(),
+			ReplicaSetUUID: randomUUID(),
+			Perf:           perf,
+			ReconnectDelay: 10 * time.Millisecond,
+		},
+		Store:       store,
+		SavePackets: 2,
+		OnReconnect: func(_ int, err error) {
+			reconnects <- err
+		},
+	})
+	require.NoError(err)
+	defer c.Close()
+
+	expect := func(lsn uint64) {
+		select {
+		case p := <-c.Packets():
+			require.NotNil(p)
+			assert.Equal(lsn, p.LSN)
+			assert.Equal([]interface{}{int64(lsn)}, p.Request.(*Insert).Tuple)
+		case <-time.After(time.Second):
+			t.Fatalf("no packet %d", lsn)
+		}
+	}
+
+	var sub testSubscribe
+	select {
+	case sub = <-m.subscribes:
+	case <-time.After(time.Second):
+		t.Fatal("no subscribe")
+	}
+	assert.Equal(VectorClock{0, 10}, sub.vclock)
+
+	ts := time.Now().Add(-2 * time.Second)
+	writeTestInsert(sub.conn, 11, ts)
+	writeTestInsert(sub.conn, 12, ts)
+	writeTestInsert(sub.conn, 13, ts)
+	expect(11)
+	expect(12)
+	expect(13)
+
+	assert.InDelta(2, perf.ReplicationLag.Value(), 0.5)
+	assert.Equal("7", perf.ReplicationLSNLag.Get("1").String())
+
+	// the connection is restored since the packets delivered
+	sub.conn.Close()
+	select {
+	case err := <-reconnects:
+		assert.Error(err)
+	case <-time.After(time.Second):
+		t.Fatal("no reconnect")
+	}
+
+	select {
+	case sub = <-m.subscribes:
+	case <-time.After(time.Second):
+		t.Fatal("no subscribe")
+	}
+	assert.Equal(VectorClock{0, 13}, sub.vclock)
+
+	// the rows delivered already are skipped
+	writeTestInsert(sub.conn, 12, time.Now())
+	writeTestInsert(sub.conn, 14, time.Now())
+	writeTestInsert(sub.conn, 15, time.Now())
+	expect(14)
+	expect(15)
+
+	assert.Equal(VectorClock{0, 15}, c.VClock())
+	assert.Equal("5", perf.ReplicationLSNLag.Get("1").String())
+
+	saved, err := store.Load()
+	require.NoError(err)
+	assert.Equal(VectorClock{0, 12}, saved)
+
+	require.NoError(c.Close())
+	_, ok := <-c.Packets()
+	assert.False(ok)
+	assert.NoError(c.Err())
+}
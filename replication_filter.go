@@ -0,0 +1,89 @@
+package tarantool
+
+import (
+	"fmt"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ReplicationFilter selects the rows of the replication stream returned by Slave, see Slave.SetFilter.
+type ReplicationFilter struct {
+	// Spaces are the ids or the names of the spaces, the rows of all spaces pass if it is empty.
+	Spaces []interface{}
+	// Commands are the types of the rows, e.g. InsertCommand or DeleteCommand,
+	// the rows of all types pass if it is empty.
+	Commands []uint
+}
+
+// replicationFilter is ReplicationFilter with the names of the spaces resolved.
+type replicationFilter struct {
+	spaces   map[uint]bool
+	commands map[uint]bool
+}
+
+// pass checks the row by the command of its header and the space of its body,
+// the rest of the body is skipped without decoding.
+func (f *replicationFilter) pass(cmd uint, body []byte) (bool, error) {
+	if len(f.commands) > 0 && !f.commands[cmd] {
+		return false, nil
+	}
+	if len(f.spaces) == 0 {
+		return true, nil
+	}
+
+	n, buf, err := msgp.ReadMapHeaderBytes(body)
+	if err != nil {
+		return false, err
+	}
+	for ; n > 0; n-- {
+		var key uint
+		if key, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return false, err
+		}
+		if key != KeySpaceNo {
+			if buf, err = msgp.Skip(buf); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		var space uint
+		if space, _, err = msgp.ReadUintBytes(buf); err != nil {
+			return false, err
+		}
+		return f.spaces[space], nil
+	}
+
+	// e.g. NOP rows have no space
+	return false, nil
+}
+
+// newReplicationFilter resolves the names of the spaces with the given func.
+func newReplicationFilter(filter *ReplicationFilter, spaceNo func(name string) (uint, error)) (*replicationFilter, error) {
+	f := &replicationFilter{
+		spaces:   make(map[uint]bool),
+		commands: make(map[uint]bool),
+	}
+
+	for _, space := range filter.Spaces {
+		if name, ok := space.(string); ok {
+			id, err := spaceNo(name)
+			if err != nil {
+				return nil, err
+			}
+			f.spaces[id] = true
+			continue
+		}
+
+		id, err := numberToUint64(space)
+		if err != nil {
+			return nil, fmt.Errorf("bad space %#v in replication filter", space)
+		}
+		f.spaces[uint(id)] = true
+	}
+
+	for _, cmd := range filter.Commands {
+		f.commands[cmd] = true
+	}
+	return f, nil
+}
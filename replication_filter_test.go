@@ -0,0 +1,77 @@
+package tarantool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicationFilter(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	m := newTestMaster(t, false)
+	defer m.l.Close()
+
+	store := &MemoryPositionStore{}
+	require.NoError(store.Save(NewVectorClock(10)))
+
+	c, err := NewReplicationConsumer(m.l.Addr().String(), &ReplicationConsumerOptions{
+		Options: Options{UUID: randomUUID(), ReplicaSetUUID: randomUUID()},
+		Store:   store,
+		Filter: &ReplicationFilter{
+			Spaces:   []interface{}{"orders", 514},
+			Commands: []uint{InsertCommand, ReplaceCommand},
+		},
+	})
+	require.NoError(err)
+	defer c.Close()
+
+	var sub testSubscribe
+	select {
+	case sub = <-m.subscribes:
+	case <-time.After(time.Second):
+		t.Fatal("no subscribe")
+	}
+
+	now := time.Now()
+	writeTestQuery(sub.conn, 11, now, &Insert{Space: 512, Tuple: []interface{}{"skipped"}})
+	writeTestQuery(sub.conn, 12, now, &Insert{Space: 513, Tuple: []interface{}{"order"}})
+	writeTestQuery(sub.conn, 13, now, &Delete{Space: 513, Index: 0, Key: "order"})
+	writeTestQuery(sub.conn, 14, now, &Replace{Space: 514, Tuple: []interface{}{"item"}})
+	writeTestQuery(sub.conn, 15, now, &Update{Space: 514, Index: 0, Key: "item", Set: []Operator{&OpAssign{Field: 1, Argument: 1}}})
+
+	for _, expected := range []Query{
+		&Insert{Space: uint(513), Tuple: []interface{}{"order"}},
+		&Replace{Space: uint(514), Tuple: []interface{}{"item"}},
+	} {
+		select {
+		case p := <-c.Packets():
+			assert.Equal(expected, p.Request)
+		case <-time.After(time.Second):
+			t.Fatal("no packet")
+		}
+	}
+
+	// the skipped rows move the position too
+	writeTestQuery(sub.conn, 16, now, &Insert{Space: 513, Tuple: []interface{}{"last"}})
+	select {
+	case p := <-c.Packets():
+		assert.Equal(uint64(16), p.LSN)
+	case <-time.After(time.Second):
+		t.Fatal("no packet")
+	}
+	assert.Equal(VectorClock{0, 16}, c.VClock())
+
+	s, err := NewSlave(m.l.Addr().String())
+	require.NoError(err)
+	defer s.Close()
+
+	err = s.SetFilter(&ReplicationFilter{Spaces: []interface{}{"missing"}})
+	if qerr, ok := err.(*QueryError); assert.True(ok) {
+		assert.Equal(ErrNoSuchSpace, qerr.Code)
+	}
+	assert.Error(s.SetFilter(&ReplicationFilter{Spaces: []interface{}{1.5}}))
+}
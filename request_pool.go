@@ -22,6 +22,9 @@ func (p *cappedRequestPool) Get() (r *request) {
 		r.opaque = nil
 		r.replyChan = nil
 		r.resultMode = ResultDefaultMode
+		r.resultDest = nil
+		r.streamID = 0
+		r.schemaless = false
 	default:
 		r = &request{}
 	}
@@ -29,12 +29,25 @@ type Result struct {
 	Data    [][]interface{}
 	RawData interface{}
 
+	// SQL response parts, see Execute and Prepare.
+	Metadata     []ColumnMetaData
+	BindMetadata []ColumnMetaData
+	BindCount    uint64
+	SQLInfo      *SQLInfo
+	StatementID  uint64
+
 	unmarshalMode resultUnmarshalMode
+	dest          interface{}
 }
 
 func (r *Result) GetCommandID() uint {
 	if r.Error != nil {
-		return r.ErrorCode | ErrorFlag
+		code := r.ErrorCode
+		var boxErr *BoxError
+		if code == OKCommand && errors.As(r.Error, &boxErr) {
+			code = boxErr.Code
+		}
+		return code | ErrorFlag
 	}
 	return r.ErrorCode
 }
@@ -43,33 +56,98 @@ func (r *Result) GetCommandID() uint {
 func (r *Result) MarshalMsg(b []byte) (o []byte, err error) {
 	o = b
 	if r.Error != nil {
+		var boxErr *BoxError
+		if errors.As(r.Error, &boxErr) {
+			o = msgp.AppendMapHeader(o, 2)
+			o = msgp.AppendUint(o, KeyError)
+			o = msgp.AppendString(o, r.Error.Error())
+			o = msgp.AppendUint(o, KeyErrorStack)
+			o = appendBoxErrorStack(o, boxErr)
+		} else {
+			o = msgp.AppendMapHeader(o, 1)
+			o = msgp.AppendUint(o, KeyError)
+			o = msgp.AppendString(o, r.Error.Error())
+		}
+	} else if r.SQLInfo != nil && r.Data == nil && r.RawData == nil {
+		// response to SQL statements which modify data has no data at all
 		o = msgp.AppendMapHeader(o, 1)
-		o = msgp.AppendUint(o, KeyError)
-		o = msgp.AppendString(o, r.Error.Error())
+		o = msgp.AppendUint(o, KeySQLInfo)
+		if o, err = r.SQLInfo.MarshalMsg(o); err != nil {
+			return nil, err
+		}
 	} else {
-		o = msgp.AppendMapHeader(o, 1)
-		o = msgp.AppendUint(o, KeyData)
-		switch {
-		case r.Data != nil:
-			if o, err = msgp.AppendIntf(o, r.Data); err != nil {
-				return nil, err
-			}
-		case r.RawData != nil:
-			if o, err = msgp.AppendIntf(o, r.RawData); err != nil {
-				return nil, err
+		// response to Prepare has no data unless it is set explicitly
+		withData := r.StatementID == 0 || r.Data != nil || r.RawData != nil
+		if o, err = r.marshalSQLParts(o, withData); err != nil {
+			return nil, err
+		}
+		if withData {
+			o = msgp.AppendUint(o, KeyData)
+			switch {
+			case r.Data != nil:
+				if o, err = msgp.AppendIntf(o, r.Data); err != nil {
+					return nil, err
+				}
+			case r.RawData != nil:
+				if o, err = msgp.AppendIntf(o, r.RawData); err != nil {
+					return nil, err
+				}
+			default:
+				o = msgp.AppendArrayHeader(o, 0)
 			}
-		default:
-			o = msgp.AppendArrayHeader(o, 0)
 		}
 	}
 
 	return o, nil
 }
 
+// marshalSQLParts appends the map header and the SQL response parts that precede data.
+func (r *Result) marshalSQLParts(o []byte, withData bool) (_ []byte, err error) {
+	n := uint32(0)
+	if withData {
+		n++
+	}
+	if r.Metadata != nil {
+		n++
+	}
+	if r.StatementID != 0 {
+		n += 3
+	}
+	if r.SQLInfo != nil {
+		n++
+	}
+	o = msgp.AppendMapHeader(o, n)
+
+	if r.StatementID != 0 {
+		o = msgp.AppendUint(o, KeyStmtID)
+		o = msgp.AppendUint64(o, r.StatementID)
+		o = msgp.AppendUint(o, KeyBindCount)
+		o = msgp.AppendUint64(o, r.BindCount)
+		o = msgp.AppendUint(o, KeyBindMetadata)
+		if o, err = marshalColumnMetaData(r.BindMetadata, o); err != nil {
+			return o, err
+		}
+	}
+	if r.Metadata != nil {
+		o = msgp.AppendUint(o, KeyMetadata)
+		if o, err = marshalColumnMetaData(r.Metadata, o); err != nil {
+			return o, err
+		}
+	}
+	if r.SQLInfo != nil {
+		o = msgp.AppendUint(o, KeySQLInfo)
+		if o, err = r.SQLInfo.MarshalMsg(o); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
 // UnmarshalMsg implements msgp.Unmarshaler
 func (r *Result) UnmarshalMsg(data []byte) (buf []byte, err error) {
 	var l uint32
 	var errorMessage string
+	var boxErr *BoxError
 
 	buf = data
 
@@ -92,15 +170,27 @@ func (r *Result) UnmarshalMsg(data []byte) (buf []byte, err error) {
 
 		switch cd {
 		case KeyData:
+			if r.dest != nil {
+				// SQL columns are matched by names
+				var names []string
+				for _, md := range r.Metadata {
+					names = append(names, md.Name)
+				}
+				if buf, err = decodeTuples(buf, r.dest, names); err != nil {
+					return
+				}
+				continue
+			}
+
 			switch r.unmarshalMode {
 			case ResultAsDataWithFallback:
 				obuf := buf
 				r.Data, buf, err = r.UnmarshalTuplesArray(buf, false)
 				if err != nil && errors.As(err, &msgp.TypeError{}) {
-					r.RawData, buf, err = msgp.ReadIntfBytes(obuf)
+					r.RawData, buf, err = readIntfBytes(obuf)
 				}
 			case ResultAsRawData:
-				r.RawData, buf, err = msgp.ReadIntfBytes(buf)
+				r.RawData, buf, err = readIntfBytes(buf)
 			default:
 				r.Data, buf, err = r.UnmarshalTuplesArray(buf, true)
 			}
@@ -113,7 +203,37 @@ func (r *Result) UnmarshalMsg(data []byte) (buf []byte, err error) {
 			if err != nil {
 				return
 			}
-			r.Error = NewQueryError(r.ErrorCode, errorMessage)
+			if boxErr == nil {
+				r.Error = NewQueryError(r.ErrorCode, errorMessage)
+			}
+		case KeyErrorStack:
+			if boxErr, buf, err = unmarshalBoxErrorStack(buf); err != nil {
+				return
+			}
+			if boxErr != nil {
+				r.Error = &QueryError{Code: r.ErrorCode, error: boxErr}
+			}
+		case KeyMetadata:
+			if r.Metadata, buf, err = unmarshalColumnMetaData(buf); err != nil {
+				return
+			}
+		case KeyBindMetadata:
+			if r.BindMetadata, buf, err = unmarshalColumnMetaData(buf); err != nil {
+				return
+			}
+		case KeyBindCount:
+			if r.BindCount, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		case KeyStmtID:
+			if r.StatementID, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		case KeySQLInfo:
+			r.SQLInfo = &SQLInfo{}
+			if buf, err = r.SQLInfo.UnmarshalMsg(buf); err != nil {
+				return
+			}
 		default:
 			if buf, err = msgp.Skip(buf); err != nil {
 				return
@@ -142,7 +262,7 @@ func (*Result) UnmarshalTuplesArray(buf []byte, force bool) ([][]interface{}, []
 		if tl, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
 			buf = obuf
 			if _, ok := err.(msgp.TypeError); ok && force {
-				if val, buf, err = msgp.ReadIntfBytes(buf); err != nil {
+				if val, buf, err = readIntfBytes(buf); err != nil {
 					return nil, nil, err
 				}
 				data[i] = []interface{}{val}
@@ -153,7 +273,7 @@ func (*Result) UnmarshalTuplesArray(buf []byte, force bool) ([][]interface{}, []
 
 		data[i] = make([]interface{}, tl)
 		for j = 0; j < tl; j++ {
-			if data[i][j], buf, err = msgp.ReadIntfBytes(buf); err != nil {
+			if data[i][j], buf, err = readIntfBytes(buf); err != nil {
 				return nil, nil, err
 			}
 		}
@@ -172,7 +292,18 @@ func (r *Result) String() string {
 		return fmt.Sprintf("Result Data:%#v", r.Data)
 	case r.RawData != nil:
 		return fmt.Sprintf("Result RawData:%#v", r.RawData)
+	case r.SQLInfo != nil:
+		return fmt.Sprintf("Result SQLInfo:%+v", *r.SQLInfo)
 	default:
 		return ""
 	}
 }
+
+// errorResult returns the result with the error, the code of QueryError is kept.
+func errorResult(err error, code uint) *Result {
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		code = qe.Code
+	}
+	return &Result{ErrorCode: code, Error: err}
+}
@@ -0,0 +1,102 @@
+package tarantool
+
+import (
+	"context"
+)
+
+// schemaReload is the schema reload in progress, concurrent requests share it.
+type schemaReload struct {
+	done chan struct{}
+	err  error
+}
+
+func (conn *Connection) schema() (*packData, uint64) {
+	conn.schemaLock.RLock()
+	defer conn.schemaLock.RUnlock()
+	return conn.packData, conn.schemaID
+}
+
+func (conn *Connection) getPackData() *packData {
+	data, _ := conn.schema()
+	return data
+}
+
+// SchemaID returns the version of the schema the connection knows of.
+// It is sent along with the requests, and the schema is reloaded
+// once the server reports a newer one.
+func (conn *Connection) SchemaID() uint64 {
+	_, schemaID := conn.schema()
+	return schemaID
+}
+
+// startSchemaReload starts reloading of _vspace and _vindex unless it is already in progress.
+func (conn *Connection) startSchemaReload() *schemaReload {
+	conn.schemaLock.Lock()
+	defer conn.schemaLock.Unlock()
+
+	r := conn.schemaReload
+	if r == nil {
+		r = &schemaReload{done: make(chan struct{})}
+		conn.schemaReload = r
+		go conn.runSchemaReload(r)
+	}
+	return r
+}
+
+// reloadSchema waits for the schema to be reloaded.
+func (conn *Connection) reloadSchema(ctx context.Context) error {
+	r := conn.startSchemaReload()
+
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return NewContextError(ctx, conn, "Schema reload error")
+	case <-conn.exit:
+		return ConnectionClosedError(conn)
+	}
+}
+
+func (conn *Connection) runSchemaReload(r *schemaReload) {
+	var data *packData
+	var schemaID uint64
+
+	timeout := conn.queryTimeout
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	data, schemaID, r.err = conn.fetchSchema(ctx)
+
+	conn.schemaLock.Lock()
+	if r.err == nil {
+		// the reloaded schema belongs to the connection only, so it is not pooled
+		conn.packData = data
+		conn.schemaID = schemaID
+	}
+	conn.schemaReload = nil
+	conn.schemaLock.Unlock()
+
+	close(r.done)
+}
+
+// fetchSchema selects _vspace and _vindex along with other requests on the connection.
+func (conn *Connection) fetchSchema(ctx context.Context) (*packData, uint64, error) {
+	opts := []ExecOption{&schemalessOption{}, ResultModeExecOption(ResultDefaultMode)}
+
+	spaces, schemaID := conn.exec(ctx, &Select{Space: ViewSpace, Key: 0, Iterator: IterAll}, opts)
+	if spaces.Error != nil {
+		return nil, 0, spaces.Error
+	}
+
+	indexes, _ := conn.exec(ctx, &Select{Space: ViewIndex, Key: 0, Iterator: IterAll}, opts)
+	if indexes.Error != nil {
+		return nil, 0, indexes.Error
+	}
+
+	data := newPackData(conn.getPackData().defaultSpace)
+	data.loadSchema(spaces.Data, indexes.Data)
+	return data, schemaID, nil
+}
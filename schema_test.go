@@ -0,0 +1,118 @@
+package tarantool
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSchema struct {
+	sync.Mutex
+	spaces   [][]interface{}
+	reloads  int
+	selected []uint
+}
+
+func (ts *testSchema) addSpace(id uint64, name string) {
+	ts.Lock()
+	ts.spaces = append(ts.spaces, []interface{}{id, uint64(1), name, "memtx", uint64(0), map[string]interface{}{}, []interface{}{}})
+	ts.Unlock()
+}
+
+func (ts *testSchema) handler(_ context.Context, q Query) *Result {
+	ts.Lock()
+	defer ts.Unlock()
+
+	switch q := q.(type) {
+	case *Select:
+		switch q.Space {
+		case uint(ViewSpace):
+			ts.reloads++
+			return &Result{Data: ts.spaces}
+		case uint(ViewIndex):
+			return &Result{}
+		}
+		ts.selected = append(ts.selected, q.Space.(uint))
+		return &Result{Data: [][]interface{}{{uint64(1)}}}
+	case *Call:
+		// the first call fails as if the space was created by someone else
+		if q.Name == "wrong_schema" && ts.reloads < 2 {
+			return &Result{ErrorCode: ErrWrongSchemaVaersion, Error: NewQueryError(ErrWrongSchemaVaersion, "Wrong schema version")}
+		}
+		return &Result{Data: [][]interface{}{{"ok"}}}
+	}
+	return &Result{}
+}
+
+func TestSchemaReloadOnUnknownName(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	ts := &testSchema{}
+	ts.addSpace(512, "tester")
+
+	l := newIprotoListener(t, ts.handler)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	assert.Equal(uint64(1), conn.SchemaID())
+
+	res := conn.Exec(context.Background(), &Select{Space: "created", Key: uint64(1)})
+	assert.Equal(ErrNoSuchSpace, res.ErrorCode)
+
+	pooled := func() int {
+		globalPackDataPool.Lock()
+		defer globalPackDataPool.Unlock()
+		return len(globalPackDataPool.pool)
+	}
+	n := pooled()
+
+	ts.addSpace(513, "created")
+
+	res = conn.Exec(context.Background(), &Select{Space: "created", Key: uint64(1)})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{int64(1)}}, res.Data)
+	assert.Equal(n, pooled())
+
+	ts.Lock()
+	assert.Equal([]uint{513}, ts.selected)
+	assert.Equal(3, ts.reloads)
+	ts.Unlock()
+}
+
+func TestSchemaReloadOnWrongSchemaVersion(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	ts := &testSchema{}
+	ts.addSpace(512, "tester")
+
+	l := newIprotoListener(t, ts.handler)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	res := conn.Exec(context.Background(), &Call{Name: "wrong_schema"})
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{"ok"}}, res.Data)
+
+	ts.Lock()
+	assert.Equal(2, ts.reloads)
+	ts.Unlock()
+
+	// the retry is done once only
+	ts.Lock()
+	ts.reloads = 0
+	ts.Unlock()
+
+	res = conn.Exec(context.Background(), &Call{Name: "wrong_schema"})
+	assert.Equal(ErrWrongSchemaVaersion, res.ErrorCode)
+}
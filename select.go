@@ -123,7 +123,7 @@ func (q *Select) UnmarshalMsg(data []byte) (buf []byte, err error) {
 				return
 			}
 		case KeyKey:
-			t, buf, err = msgp.ReadIntfBytes(buf)
+			t, buf, err = readIntfBytes(buf)
 			if err != nil {
 				return buf, err
 			}
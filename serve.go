@@ -0,0 +1,186 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrServerClosed is returned by Server.Serve after Shutdown or Close.
+var ErrServerClosed = errors.New("server closed")
+
+type ServerOptions struct {
+	IprotoServerOptions
+	// MaxConnections limits the number of sessions, connections over the limit are closed
+	// right after they are accepted. If it is 0, the number is not limited.
+	MaxConnections int
+}
+
+// Server accepts connections and serves each one with its own IprotoServer session,
+// like http.Server does for HTTP.
+type Server struct {
+	uuid    string
+	handler QueryHandler
+	opts    ServerOptions
+
+	// acceptLock makes close wait for the sessions being accepted
+	acceptLock sync.RWMutex
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	sessions  map[*IprotoServer]struct{}
+	closed    bool
+}
+
+func NewServer(uuid string, handler QueryHandler, opts *ServerOptions) *Server {
+	srv := &Server{
+		uuid:      uuid,
+		handler:   handler,
+		listeners: make(map[net.Listener]struct{}),
+		sessions:  make(map[*IprotoServer]struct{}),
+	}
+	if opts != nil {
+		srv.opts = *opts
+	}
+	return srv
+}
+
+// Serve accepts connections on the listener until Shutdown or Close is called,
+// then it returns ErrServerClosed.
+func (srv *Server) Serve(l net.Listener) error {
+	srv.mu.Lock()
+	if srv.closed {
+		srv.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	srv.listeners[l] = struct{}{}
+	srv.mu.Unlock()
+
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.listeners, l)
+		srv.mu.Unlock()
+		l.Close()
+	}()
+
+	var delay time.Duration
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if srv.isClosed() {
+				return ErrServerClosed
+			}
+			// retry on temporary errors like too many open files
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay *= 2; delay > time.Second {
+					delay = time.Second
+				}
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+
+		srv.acceptLock.RLock()
+		if s := srv.newSession(); s != nil {
+			s.Accept(conn)
+		} else {
+			conn.Close()
+		}
+		srv.acceptLock.RUnlock()
+	}
+}
+
+// newSession returns nil if the server is closed or the limit of sessions is reached.
+func (srv *Server) newSession() *IprotoServer {
+	var s *IprotoServer
+
+	s = NewIprotoServer(srv.uuid, srv.handler, func(error) {
+		srv.mu.Lock()
+		delete(srv.sessions, s)
+		srv.mu.Unlock()
+	}).WithOptions(&srv.opts.IprotoServerOptions)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.closed || (srv.opts.MaxConnections > 0 && len(srv.sessions) >= srv.opts.MaxConnections) {
+		return nil
+	}
+	srv.sessions[s] = struct{}{}
+	return s
+}
+
+func (srv *Server) isClosed() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.closed
+}
+
+// Sessions returns the number of the sessions being served.
+func (srv *Server) Sessions() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return len(srv.sessions)
+}
+
+// close stops accepting connections and returns the sessions.
+func (srv *Server) close() []*IprotoServer {
+	srv.acceptLock.Lock()
+	defer srv.acceptLock.Unlock()
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.closed = true
+	for l := range srv.listeners {
+		l.Close()
+	}
+
+	sessions := make([]*IprotoServer, 0, len(srv.sessions))
+	for s := range srv.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Shutdown stops accepting connections and drains the sessions: requests being handled
+// are finished and their responses are written before the connections are closed.
+// If the context is done first, the remaining sessions are closed and the context error is returned.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	sessions := srv.close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(sessions))
+	for _, s := range sessions {
+		wg.Add(1)
+		go func(s *IprotoServer) {
+			defer wg.Done()
+			if err := s.Drain(ctx); err != nil {
+				errs <- err
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Close stops accepting connections and closes all the sessions immediately.
+func (srv *Server) Close() error {
+	for _, s := range srv.close() {
+		s.Shutdown()
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package tarantool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handler QueryHandler, opts *ServerOptions) (*Server, string, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer("1", handler, opts)
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+	return srv, l.Addr().String(), served
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	srv, addr, served := newTestServer(t, func(context.Context, Query) *Result {
+		return &Result{}
+	}, &ServerOptions{MaxConnections: 2})
+
+	var conns []*Connection
+	for i := 0; i < 2; i++ {
+		conn, err := Connect(addr, nil)
+		require.NoError(err)
+		conns = append(conns, conn)
+	}
+	assert.Equal(2, srv.Sessions())
+
+	_, err := Connect(addr, &Options{ConnectTimeout: 200 * time.Millisecond})
+	assert.Error(err)
+
+	// the session is released once the client is gone
+	conns[0].Close()
+	require.Eventually(func() bool { return srv.Sessions() == 1 }, time.Second, 10*time.Millisecond)
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	require.NoError(conn.Exec(context.Background(), &Ping{}).Error)
+
+	require.NoError(srv.Close())
+	assert.Equal(ErrServerClosed, <-served)
+
+	<-conn.exit
+	<-conns[1].exit
+	assert.Equal(0, srv.Sessions())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	assert.Equal(ErrServerClosed, srv.Serve(l))
+}
+
+func TestServerShutdown(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	srv, addr, served := newTestServer(t, func(_ context.Context, q Query) *Result {
+		if call, ok := q.(*Call); ok && call.Name == "slow" {
+			close(started)
+			<-release
+			return &Result{Data: [][]interface{}{{"done"}}}
+		}
+		return &Result{}
+	}, nil)
+
+	conn, err := Connect(addr, nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	result := make(chan *Result, 1)
+	go func() {
+		result <- conn.Exec(context.Background(), &Call{Name: "slow"})
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(context.Background())
+	}()
+	assert.Equal(ErrServerClosed, <-served)
+
+	// new connections are not accepted while the session is drained
+	_, err = Connect(addr, &Options{ConnectTimeout: 200 * time.Millisecond})
+	assert.Error(err)
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown has not waited for the request")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(<-shutdown)
+
+	res := <-result
+	require.NoError(res.Error)
+	assert.Equal([][]interface{}{{"done"}}, res.Data)
+
+	<-conn.exit
+	assert.Equal(0, srv.Sessions())
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	srv, addr, _ := newTestServer(t, func(_ context.Context, q Query) *Result {
+		if _, ok := q.(*Call); ok {
+			<-release
+		}
+		return &Result{}
+	}, nil)
+
+	conn, err := Connect(addr, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	go conn.Exec(context.Background(), &Call{Name: "stuck"})
+	require.Eventually(t, func() bool { return srv.Sessions() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, srv.Shutdown(ctx))
+
+	<-conn.exit
+}
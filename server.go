@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const saltSize = 32
@@ -18,6 +21,47 @@ type OnShutdownCallback func(err error)
 
 func defaultPingStatus(*IprotoServer) uint { return OKCommand }
 
+type streamIDContextKey struct{}
+
+// sessionContextKey is the key of the IprotoServer which has received the request.
+type sessionContextKey struct{}
+
+// StreamIDFromContext returns the stream id of the request passed to QueryHandler, if any.
+// The requests of the same stream are handled one by one in the order they are received.
+func StreamIDFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(streamIDContextKey{}).(uint64)
+	return id, ok
+}
+
+// streamQueue keeps the requests of every stream of the session in the order they are received.
+type streamQueue struct {
+	sync.Mutex
+	last map[uint64]chan struct{} // closed once the last request of the stream is handled
+}
+
+// enqueue returns the channel closed once the previous request of the stream is handled,
+// it is nil if there is none, and the function to call once the request is handled.
+func (q *streamQueue) enqueue(id uint64) (<-chan struct{}, func()) {
+	done := make(chan struct{})
+
+	q.Lock()
+	if q.last == nil {
+		q.last = make(map[uint64]chan struct{})
+	}
+	prev := q.last[id]
+	q.last[id] = done
+	q.Unlock()
+
+	return prev, func() {
+		close(done)
+		q.Lock()
+		if q.last[id] == done {
+			delete(q.last, id)
+		}
+		q.Unlock()
+	}
+}
+
 type IprotoServer struct {
 	sync.Mutex
 	conn          net.Conn
@@ -36,11 +80,51 @@ type IprotoServer struct {
 	schemaID      uint64
 	wg            sync.WaitGroup
 	getPingStatus func(*IprotoServer) uint
+	protocolInfo  *ProtocolInfo
+	peerInfo      ProtocolInfo
+	events        map[string]interface{}
+	watches       map[string]*serverWatch
+	tlsConfig     *tls.Config
+	credentials   CredentialStore
+	authPolicy    AuthPolicy
+	acl           map[string]UserACL
+	user          string // empty until the session is authenticated
+	draining      int32
+	closed        chan struct{}
+	maxInFlight   int
+}
+
+// serverWatch tracks notifications about the key watched by the client.
+type serverWatch struct {
+	pending bool // the event has been sent but not acknowledged yet
+	changed bool // the value has been changed while waiting for the acknowledgement
 }
 
 type IprotoServerOptions struct {
 	Perf          PerfCount
 	GetPingStatus func(*IprotoServer) uint
+	// ProtocolInfo enables IPROTO_ID support: the server greets clients as Tarantool 2.10.0
+	// and answers IPROTO_ID with the given protocol version and features.
+	// If FeatureWatchers is listed, IPROTO_WATCH and IPROTO_UNWATCH are served by the server itself,
+	// see Broadcast.
+	ProtocolInfo *ProtocolInfo
+	// TLSConfig makes the server wrap accepted connections with TLS.
+	TLSConfig *tls.Config
+	// Credentials makes the server verify Auth requests itself instead of passing them to the handler.
+	// Otherwise the session is authenticated once the handler answers Auth without an error.
+	Credentials CredentialStore
+	// AuthPolicy and ACL restrict the requests passed to the handler,
+	// ACL is keyed by user names including GuestUser. If ACL is set,
+	// users without an entry are denied.
+	AuthPolicy AuthPolicy
+	ACL        map[string]UserACL
+	// MaxInFlight limits the number of requests handled concurrently by the session,
+	// the next request is not read from the connection until one of them is finished.
+	// If it is 0, the number is not limited.
+	MaxInFlight int
+	// Sequential makes the session handle its requests one by one in the order they are received,
+	// so the responses are sent in the same order. It takes precedence over MaxInFlight.
+	Sequential bool
 }
 
 func NewIprotoServer(uuid string, handler QueryHandler, onShutdown OnShutdownCallback) *IprotoServer {
@@ -53,6 +137,7 @@ func NewIprotoServer(uuid string, handler QueryHandler, onShutdown OnShutdownCal
 		uuid:          uuid,
 		schemaID:      1,
 		getPingStatus: defaultPingStatus,
+		closed:        make(chan struct{}),
 	}
 }
 
@@ -64,13 +149,116 @@ func (s *IprotoServer) WithOptions(opts *IprotoServerOptions) *IprotoServer {
 	if opts.GetPingStatus != nil {
 		s.getPingStatus = opts.GetPingStatus
 	}
+	if opts.ProtocolInfo != nil {
+		info := *opts.ProtocolInfo
+		if info.Version == 0 {
+			info.Version = ProtocolVersion
+		}
+		s.protocolInfo = &info
+	}
+	s.tlsConfig = opts.TLSConfig
+	s.credentials = opts.Credentials
+	s.authPolicy = opts.AuthPolicy
+	s.acl = opts.ACL
+	s.maxInFlight = opts.MaxInFlight
+	if opts.Sequential {
+		s.maxInFlight = 1
+	}
 	return s
 }
 
+// ProtocolInfo returns the protocol version and features negotiated with the client.
+// It is empty unless the client has sent IPROTO_ID.
+func (s *IprotoServer) ProtocolInfo() ProtocolInfo {
+	s.Lock()
+	defer s.Unlock()
+	return s.peerInfo
+}
+
+// Broadcast sets the value of the key like box.broadcast does
+// and notifies the client if it watches the key.
+func (s *IprotoServer) Broadcast(key string, value interface{}) {
+	s.Lock()
+	if s.events == nil {
+		s.events = make(map[string]interface{})
+	}
+	s.events[key] = value
+
+	notify := false
+	if w := s.watches[key]; w != nil {
+		if w.pending {
+			w.changed = true
+		} else {
+			w.pending = true
+			notify = true
+		}
+	}
+	s.Unlock()
+
+	if notify {
+		s.sendEvent(key, value)
+	}
+}
+
+func (s *IprotoServer) hasFeature(feature ProtocolFeature) bool {
+	return s.protocolInfo != nil && s.protocolInfo.Has(feature)
+}
+
+// watch registers the key or handles the acknowledgement of the sent event.
+func (s *IprotoServer) watch(key string) {
+	s.Lock()
+	if s.watches == nil {
+		s.watches = make(map[string]*serverWatch)
+	}
+
+	notify := false
+	if w := s.watches[key]; w == nil {
+		s.watches[key] = &serverWatch{pending: true}
+		notify = true
+	} else if w.pending {
+		w.pending = w.changed
+		notify = w.changed
+		w.changed = false
+	}
+	value := s.events[key]
+	s.Unlock()
+
+	if notify {
+		s.sendEvent(key, value)
+	}
+}
+
+func (s *IprotoServer) unwatch(key string) {
+	s.Lock()
+	delete(s.watches, key)
+	s.Unlock()
+}
+
+func (s *IprotoServer) sendEvent(key string, value interface{}) {
+	pp := packetPool.GetWithID(0)
+	if err := pp.packMsg(&Event{Key: key, Data: value}, nil); err != nil {
+		pp.Release()
+		s.setError(err)
+		s.Shutdown()
+		return
+	}
+
+	pp.packet.SchemaID = s.schemaID
+	select {
+	case s.output <- pp:
+	case <-s.ctx.Done():
+		pp.Release()
+	}
+}
+
 func (s *IprotoServer) Accept(conn net.Conn) {
 	var ccr io.Reader
 	var ccw io.Writer
 
+	if s.tlsConfig != nil {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+
 	if s.perf.NetRead != nil {
 		ccr = NewCountedReader(conn, s.perf.NetRead)
 	} else {
@@ -89,6 +277,30 @@ func (s *IprotoServer) Accept(conn net.Conn) {
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.output = make(chan *BinaryPacket, 1024)
 
+	// the handshake must not block the caller accepting other connections
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		go func() {
+			tlsConn.SetDeadline(time.Now().Add(DefaultConnectTimeout))
+			err := tlsConn.Handshake()
+			tlsConn.SetDeadline(time.Time{})
+			if err != nil {
+				s.setError(err)
+				s.Shutdown()
+				return
+			}
+			if s.isDraining() {
+				s.Shutdown()
+				return
+			}
+			s.start()
+		}()
+		return
+	}
+
+	s.start()
+}
+
+func (s *IprotoServer) start() {
 	err := s.greet()
 	if err != nil {
 		s.Shutdown()
@@ -98,22 +310,23 @@ func (s *IprotoServer) Accept(conn net.Conn) {
 	go s.loop()
 }
 
+// CheckAuth checks the chap-sha1 scramble received from the client.
 func (s *IprotoServer) CheckAuth(hash []byte, password string) bool {
-	scr, err := scramble(s.salt, password)
-	if err != nil {
-		return false
-	}
+	return chapSha1{}.Verify(s.salt, hash, password)
+}
 
-	if len(scr) != len(hash) {
+// VerifyAuth checks the authentication request received from the client
+// with the method of the request, see RegisterAuthMethod.
+// The plain password of pap-sha256 is only accepted over TLS.
+func (s *IprotoServer) VerifyAuth(auth *Auth, password string) bool {
+	method, ok := getAuthMethod(auth.Method)
+	if !ok {
 		return false
 	}
-
-	for i, v := range hash {
-		if v != scr[i] {
-			return false
-		}
+	if method.Name() == AuthPapSha256 && !s.isSecure() {
+		return false
 	}
-	return true
+	return method.Verify(s.salt, auth.GreetingAuth, password)
 }
 
 func (s *IprotoServer) setError(err error) {
@@ -140,15 +353,41 @@ func (s *IprotoServer) Shutdown() error {
 		if s.onShutdown != nil {
 			s.onShutdown(err)
 		}
+		// interrupt the blocked reader, the writer is stopped by the context
+		s.conn.SetReadDeadline(time.Now())
 		go func() {
 			s.wg.Wait()
 			s.conn.Close()
+			close(s.closed)
 		}()
 	})
 
 	return err
 }
 
+// Drain stops reading requests, waits for the requests being handled
+// and writes their responses before closing the connection.
+// The connection is closed immediately once the context is done.
+func (s *IprotoServer) Drain(ctx context.Context) error {
+	if atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		s.conn.SetReadDeadline(time.Now())
+	}
+
+	select {
+	case <-s.closed:
+		return nil
+	case <-ctx.Done():
+		s.Shutdown()
+		// do not wait for the handlers
+		s.conn.Close()
+		return ctx.Err()
+	}
+}
+
+func (s *IprotoServer) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) != 0
+}
+
 func (s *IprotoServer) greet() (err error) {
 	var line1, line2 string
 	var format, greeting string
@@ -162,7 +401,11 @@ func (s *IprotoServer) greet() (err error) {
 
 	s.salt = []byte(base64.StdEncoding.EncodeToString(salt))
 
-	line1 = fmt.Sprintf("%s %s", ServerIdent, s.uuid)
+	ident := ServerIdent
+	if s.protocolInfo != nil {
+		ident = ServerIdentID
+	}
+	line1 = fmt.Sprintf("%s %s", ident, s.uuid)
 	line2 = string(s.salt)
 
 	format = fmt.Sprintf("%%-%ds\n%%-%ds\n", GreetingSize/2-1, GreetingSize/2-1)
@@ -197,9 +440,24 @@ func (s *IprotoServer) read() {
 
 	r := s.reader
 	var wg sync.WaitGroup
+	var streams streamQueue
+
+	// the slots of the requests being handled, the next request is read once a slot is free
+	var inFlight chan struct{}
+	if s.maxInFlight > 0 {
+		inFlight = make(chan struct{}, s.maxInFlight)
+	}
 
 READER_LOOP:
 	for {
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+			case <-s.ctx.Done():
+				break READER_LOOP
+			}
+		}
+
 		select {
 		case <-s.ctx.Done():
 			break READER_LOOP
@@ -215,10 +473,33 @@ READER_LOOP:
 				s.perf.NetPacketsIn.Add(1)
 			}
 
+			// the requests of a stream wait for the previous ones,
+			// the header is decoded again with the body below
+			var prev <-chan struct{}
+			var handled func()
+			var header Packet
+			if _, err := header.UnmarshalBinaryHeader(pp.body); err == nil && header.StreamID != 0 {
+				prev, handled = streams.enqueue(header.StreamID)
+			}
+
 			wg.Add(1)
 			go func(pp *BinaryPacket) {
 				packet := &pp.packet
 				defer wg.Done()
+				if inFlight != nil {
+					defer func() { <-inFlight }()
+				}
+				if handled != nil {
+					defer handled()
+				}
+				if prev != nil {
+					select {
+					case <-prev:
+					case <-s.ctx.Done():
+						pp.Release()
+						return
+					}
+				}
 
 				err := packet.UnmarshalBinary(pp.body)
 
@@ -229,7 +510,35 @@ READER_LOOP:
 				}
 
 				code := packet.Cmd
-				if code == PingCommand {
+				if code == IDCommand && s.protocolInfo != nil {
+					client := packet.Request.(*ID).ProtocolInfo
+					s.Lock()
+					s.peerInfo = s.protocolInfo.intersect(client)
+					s.Unlock()
+
+					if err = pp.packMsg(&IDResponse{ID{*s.protocolInfo}}, nil); err != nil {
+						s.setError(err)
+						s.Shutdown()
+						return
+					}
+
+					pp.packet.SchemaID = s.schemaID
+					select {
+					case s.output <- pp:
+						return
+					case <-s.ctx.Done():
+						break
+					}
+				} else if code == WatchCommand && s.hasFeature(FeatureWatchers) {
+					// there is no response to watch requests, the denied ones are ignored
+					if s.checkAccess(code, packet.Request) == nil {
+						s.watch(packet.Request.(*Watch).Key)
+					}
+				} else if code == UnwatchCommand && s.hasFeature(FeatureWatchers) {
+					if s.checkAccess(code, packet.Request) == nil {
+						s.unwatch(packet.Request.(*Unwatch).Key)
+					}
+				} else if code == PingCommand {
 					pr := packetPool.GetWithID(packet.requestID)
 					pr.packet.Cmd = s.getPingStatus(s)
 					pr.packet.SchemaID = packet.SchemaID
@@ -241,7 +550,20 @@ READER_LOOP:
 						break
 					}
 				} else {
-					res := s.handler(s.ctx, packet.Request)
+					res := s.checkAccess(code, packet.Request)
+					if res == nil && code == AuthCommand && s.credentials != nil {
+						res = s.authenticate(packet.Request.(*Auth))
+					} else if res == nil {
+						ctx := context.WithValue(s.ctx, userContextKey{}, s.User())
+						ctx = context.WithValue(ctx, sessionContextKey{}, s)
+						if packet.StreamID != 0 {
+							ctx = context.WithValue(ctx, streamIDContextKey{}, packet.StreamID)
+						}
+						res = s.handler(ctx, packet.Request)
+						if code == AuthCommand && res.ErrorCode == OKCommand && res.Error == nil {
+							s.setUser(packet.Request.(*Auth).User)
+						}
+					}
 					if res.ErrorCode != OKCommand && res.Error == nil {
 						res.Error = ErrUnknownError
 					}
@@ -266,12 +588,17 @@ READER_LOOP:
 		}
 	}
 
-	if err != nil {
+	if err != nil && !s.isDraining() {
 		s.setError(err)
 	}
 	wg.Wait()
 	s.Shutdown()
 
+	// the writer sends the pending responses
+	if s.isDraining() {
+		return
+	}
+
 CLEANUP_LOOP:
 	for {
 		select {
@@ -296,6 +623,21 @@ func (s *IprotoServer) write() {
 		return err
 	}
 
+	// flush writes the pending responses if the session is drained
+	flush := func(w *bufio.Writer) error {
+		for s.isDraining() {
+			select {
+			case packet := <-s.output:
+				if err := wp(w, packet); err != nil {
+					return err
+				}
+			default:
+				return w.Flush()
+			}
+		}
+		return w.Flush()
+	}
+
 WRITER_LOOP:
 	for {
 		select {
@@ -307,7 +649,7 @@ WRITER_LOOP:
 				break WRITER_LOOP
 			}
 		case <-s.ctx.Done():
-			w.Flush()
+			err = flush(w)
 			break WRITER_LOOP
 		default:
 			if err = w.Flush(); err != nil {
@@ -324,7 +666,7 @@ WRITER_LOOP:
 					break WRITER_LOOP
 				}
 			case <-s.ctx.Done():
-				w.Flush()
+				err = flush(w)
 				break WRITER_LOOP
 			}
 
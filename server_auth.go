@@ -0,0 +1,176 @@
+package tarantool
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+)
+
+// GuestUser is the user of the session until it is authenticated.
+const GuestUser = "guest"
+
+// CredentialStore provides passwords of the users authenticated by IprotoServer.
+type CredentialStore interface {
+	// Password returns the password of the user, ok is false if the user is unknown.
+	Password(user string) (password string, ok bool)
+}
+
+// StaticCredentials is CredentialStore with passwords by user names.
+type StaticCredentials map[string]string
+
+// Password implements CredentialStore
+func (c StaticCredentials) Password(user string) (string, bool) {
+	password, ok := c[user]
+	return password, ok
+}
+
+// AuthPolicy defines what unauthenticated sessions of IprotoServer are allowed to do.
+// Along with UserACL it limits the requests by their commands and spaces only:
+// Call, Eval and Execute run whatever they are given, so the handler should check
+// the functions and statements allowed to the user, see UserFromContext.
+type AuthPolicy int
+
+const (
+	// AuthOptional lets guest sessions run commands according to ACL of GuestUser.
+	AuthOptional AuthPolicy = iota
+	// AuthRequired rejects all commands but ping, IPROTO_ID and auth until the session is authenticated.
+	AuthRequired
+)
+
+// UserACL limits the commands and the spaces available to the user.
+// Note that Call, Eval and SQL queries are not limited by Spaces, see AuthPolicy,
+// and that Connection selects ViewSpace and ViewIndex on connect.
+type UserACL struct {
+	// Commands allowed to the user, e.g. SelectCommand. Any command is allowed if it is empty.
+	Commands []uint
+	// Spaces allowed to the user by id. Any space is allowed if it is empty.
+	// The server has no schema to match names: clients resolve them to ids before sending requests,
+	// so the spaces are listed by ids only, as they are in _vspace served by the handler.
+	Spaces []uint
+}
+
+func (acl *UserACL) allows(code uint, q Query) bool {
+	if len(acl.Commands) > 0 && !containsUint(acl.Commands, code) {
+		return false
+	}
+	if space, ok := querySpace(q); ok && len(acl.Spaces) > 0 && !containsUint(acl.Spaces, space) {
+		return false
+	}
+	return true
+}
+
+func containsUint(list []uint, v uint) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// querySpace returns the space id of the request decoded by the server.
+func querySpace(q Query) (uint, bool) {
+	var space interface{}
+
+	switch q := q.(type) {
+	case *Select:
+		space = q.Space
+	case *Insert:
+		space = q.Space
+	case *Replace:
+		space = q.Space
+	case *Update:
+		space = q.Space
+	case *Upsert:
+		space = q.Space
+	case *Delete:
+		space = q.Space
+	default:
+		return 0, false
+	}
+
+	id, ok := space.(uint)
+	return id, ok
+}
+
+type userContextKey struct{}
+
+// UserFromContext returns the user of the session, which has sent the request passed to QueryHandler.
+// It is GuestUser unless the session is authenticated.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userContextKey{}).(string)
+	return user, ok
+}
+
+// User returns the user the session is authenticated as or GuestUser.
+func (s *IprotoServer) User() string {
+	s.Lock()
+	defer s.Unlock()
+	if s.user == "" {
+		return GuestUser
+	}
+	return s.user
+}
+
+func (s *IprotoServer) setUser(user string) {
+	s.Lock()
+	s.user = user
+	s.Unlock()
+}
+
+// authenticate verifies the request with the credential store.
+func (s *IprotoServer) authenticate(auth *Auth) *Result {
+	password, ok := s.credentials.Password(auth.User)
+	if !ok || !s.VerifyAuth(auth, password) {
+		return &Result{
+			ErrorCode: ErrCredsMismatch,
+			Error:     NewQueryError(ErrCredsMismatch, "User not found or supplied credentials are invalid"),
+		}
+	}
+	s.setUser(auth.User)
+	return &Result{}
+}
+
+// isSecure reports whether the session is served over TLS.
+func (s *IprotoServer) isSecure() bool {
+	_, ok := s.conn.(*tls.Conn)
+	return ok
+}
+
+// checkAccess returns the error result if the user is not allowed to run the request.
+func (s *IprotoServer) checkAccess(code uint, q Query) *Result {
+	if code == AuthCommand {
+		// the plain password is neither verified nor passed to the handler
+		if auth, ok := q.(*Auth); ok && auth.Method == AuthPapSha256 && !s.isSecure() {
+			return &Result{
+				ErrorCode: ErrCredsMismatch,
+				Error:     NewQueryError(ErrCredsMismatch, ErrInsecureAuth.Error()),
+			}
+		}
+		return nil
+	}
+
+	s.Lock()
+	user := s.user
+	s.Unlock()
+
+	if user == "" {
+		if s.authPolicy == AuthRequired {
+			return &Result{
+				ErrorCode: ErrAccessDenied,
+				Error:     NewQueryError(ErrAccessDenied, "Session access denied for unauthenticated user"),
+			}
+		}
+		user = GuestUser
+	}
+	if s.acl == nil {
+		return nil
+	}
+	if acl, ok := s.acl[user]; ok && acl.allows(code, q) {
+		return nil
+	}
+	return &Result{
+		ErrorCode: ErrAccessDenied,
+		Error:     NewQueryError(ErrAccessDenied, fmt.Sprintf("Execute access to command %d denied for user '%s'", code, user)),
+	}
+}
@@ -0,0 +1,225 @@
+package tarantool
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestACLListener(t *testing.T, opts *IprotoServerOptions) (*iprotoListener, chan string) {
+	users := make(chan string, 10)
+	l := newIprotoListenerWithOptions(t, func(ctx context.Context, q Query) *Result {
+		switch q := q.(type) {
+		case *Auth:
+			// the handler authenticates unless the server has credentials
+			if q.User != "alice" {
+				return &Result{ErrorCode: ErrCredsMismatch, Error: NewQueryError(ErrCredsMismatch, "bad user")}
+			}
+		case *Call17:
+			user, _ := UserFromContext(ctx)
+			users <- user
+			return &Result{Data: [][]interface{}{{user}}}
+		}
+		return &Result{}
+	}, opts)
+	return l, users
+}
+
+// testSession talks iproto to the server without Connection, e.g. it sends the requests Connection refuses to send.
+type testSession struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	salt      []byte
+	requestID uint64
+}
+
+func dialTestSession(t *testing.T, addr string) *testSession {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+
+	greeting := make([]byte, GreetingSize)
+	r := bufio.NewReader(conn)
+	_, err = io.ReadFull(r, greeting)
+	require.NoError(t, err)
+
+	return &testSession{conn: conn, r: r, salt: greeting[GreetingSize/2 : GreetingSize/2+44]}
+}
+
+func (s *testSession) send(t *testing.T, q Query) {
+	s.requestID++
+	pp := packetPool.GetWithID(s.requestID)
+	defer pp.Release()
+	require.NoError(t, pp.packMsg(q, nil))
+	_, err := pp.WriteTo(s.conn)
+	require.NoError(t, err)
+}
+
+func (s *testSession) receive(timeout time.Duration) (*Packet, error) {
+	s.conn.SetReadDeadline(time.Now().Add(timeout))
+	pp := &BinaryPacket{}
+	if err := pp.readPacket(s.r); err != nil {
+		return nil, err
+	}
+	return &pp.packet, nil
+}
+
+func TestIprotoServerCredentials(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l, users := newTestACLListener(t, &IprotoServerOptions{
+		Credentials: StaticCredentials{"bob": "secret"},
+		AuthPolicy:  AuthRequired,
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), &Options{User: "bob", Password: "secret"})
+	require.NoError(err)
+	defer conn.Close()
+
+	res := conn.Exec(context.Background(), &Call17{Name: "whoami"})
+	require.NoError(res.Error)
+	assert.Equal("bob", <-users)
+
+	_, err = Connect(l.Addr(), &Options{User: "bob", Password: "wrong"})
+	assert.Error(err)
+	_, err = Connect(l.Addr(), &Options{User: "alice", Password: "secret"})
+	assert.Error(err)
+
+	// unauthenticated sessions can't even pull the schema
+	_, err = Connect(l.Addr(), nil)
+	assert.Error(err)
+}
+
+func TestIprotoServerWatchRequiresAuth(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l, _ := newTestACLListener(t, &IprotoServerOptions{
+		Credentials:  StaticCredentials{"bob": "secret"},
+		AuthPolicy:   AuthRequired,
+		ProtocolInfo: &ProtocolInfo{Features: []ProtocolFeature{FeatureWatchers}},
+	})
+	defer l.Close()
+
+	s := dialTestSession(t, l.Addr())
+	defer s.conn.Close()
+
+	s.send(t, &Watch{Key: "box.status"})
+	s.send(t, &Ping{})
+	p, err := s.receive(time.Second)
+	require.NoError(err)
+	assert.Equal(OKCommand, p.Cmd)
+	assert.Equal(s.requestID, p.requestID)
+
+	// no event is sent to the guest
+	_, err = s.receive(100 * time.Millisecond)
+	assert.Error(err)
+}
+
+func TestIprotoServerPapSha256RequiresTLS(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+	opts := &IprotoServerOptions{Credentials: StaticCredentials{"bob": "secret"}}
+
+	l, _ := newTestACLListener(t, opts)
+	defer l.Close()
+
+	// the password is sent in plain text
+	s := dialTestSession(t, l.Addr())
+	defer s.conn.Close()
+	s.send(t, &Auth{User: "bob", Password: "secret", GreetingAuth: s.salt, Method: AuthPapSha256})
+	p, err := s.receive(time.Second)
+	require.NoError(err)
+	assert.Equal(ErrCredsMismatch, p.Cmd&^ErrorFlag)
+
+	opts.TLSConfig = serverConfig
+	tlsListener, _ := newTestACLListener(t, opts)
+	defer tlsListener.Close()
+
+	conn, err := Connect("tls://"+tlsListener.Addr(), &Options{
+		User:       "bob",
+		Password:   "secret",
+		AuthMethod: AuthPapSha256,
+		TLSConfig:  clientConfig,
+	})
+	require.NoError(err)
+	conn.Close()
+}
+
+func TestIprotoServerHandlerAuth(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l, users := newTestACLListener(t, nil)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), &Options{User: "alice"})
+	require.NoError(err)
+	defer conn.Close()
+
+	require.NoError(conn.Exec(context.Background(), &Call17{Name: "whoami"}).Error)
+	assert.Equal("alice", <-users)
+
+	guest, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer guest.Close()
+
+	require.NoError(guest.Exec(context.Background(), &Call17{Name: "whoami"}).Error)
+	assert.Equal(GuestUser, <-users)
+}
+
+func TestIprotoServerACL(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	schema := []uint{uint(ViewSpace), uint(ViewIndex)}
+	l, _ := newTestACLListener(t, &IprotoServerOptions{
+		Credentials: StaticCredentials{"reader": "r", "writer": "w", "nobody": "n"},
+		ACL: map[string]UserACL{
+			GuestUser: {Commands: []uint{SelectCommand}, Spaces: schema},
+			"reader":  {Commands: []uint{SelectCommand}, Spaces: append(schema, 512)},
+			"writer":  {},
+		},
+	})
+	defer l.Close()
+
+	connect := func(user, password string) *Connection {
+		conn, err := Connect(l.Addr(), &Options{User: user, Password: password})
+		require.NoError(err, user)
+		return conn
+	}
+	exec := func(conn *Connection, q Query) uint {
+		return conn.Exec(context.Background(), q).ErrorCode
+	}
+
+	guest, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer guest.Close()
+	assert.Equal(ErrAccessDenied, exec(guest, &Select{Space: 512, Key: 1}))
+	assert.Equal(ErrAccessDenied, exec(guest, &Call17{Name: "whoami"}))
+	assert.Equal(OKCommand, exec(guest, &Ping{}))
+
+	reader := connect("reader", "r")
+	defer reader.Close()
+	assert.Equal(OKCommand, exec(reader, &Select{Space: 512, Key: 1}))
+	assert.Equal(ErrAccessDenied, exec(reader, &Select{Space: 513, Key: 1}))
+	assert.Equal(ErrAccessDenied, exec(reader, &Insert{Space: 512, Tuple: []interface{}{1}}))
+
+	writer := connect("writer", "w")
+	defer writer.Close()
+	assert.Equal(OKCommand, exec(writer, &Insert{Space: 513, Tuple: []interface{}{1}}))
+	assert.Equal(OKCommand, exec(writer, &Call17{Name: "whoami"}))
+
+	// no entry in ACL
+	_, err = Connect(l.Addr(), &Options{User: "nobody", Password: "n"})
+	assert.Error(err)
+}
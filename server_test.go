@@ -3,7 +3,9 @@ package tarantool
 import (
 	"context"
 	"net"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,3 +44,110 @@ func TestServerPing(t *testing.T) {
 	conn.Close()
 	s.Shutdown()
 }
+
+func TestIprotoServerMaxInFlight(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	release := make(chan struct{})
+
+	l := newIprotoListenerWithOptions(t, func(_ context.Context, q Query) *Result {
+		if _, ok := q.(*Call); !ok {
+			return &Result{}
+		}
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &Result{}
+	}, &IprotoServerOptions{MaxInFlight: 2})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(conn.Exec(context.Background(), &Call{Name: "wait"}).Error)
+		}()
+	}
+
+	require.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// the rest of the requests are not read until the slots are free
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(2, running)
+	mu.Unlock()
+
+	close(release)
+	wg.Wait()
+	assert.Equal(2, maxRunning)
+}
+
+func TestIprotoServerSequential(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	const count = 20
+
+	var mu sync.Mutex
+	var handled []uint64
+
+	l := newIprotoListenerWithOptions(t, func(_ context.Context, q Query) *Result {
+		call, ok := q.(*Call)
+		if !ok {
+			return &Result{}
+		}
+		n := uint64(call.Tuple[0].(int64))
+		// the earlier requests take longer
+		time.Sleep(time.Duration(count-n) * time.Millisecond)
+
+		mu.Lock()
+		handled = append(handled, n)
+		mu.Unlock()
+		return &Result{}
+	}, &IprotoServerOptions{Sequential: true})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	replies := make(chan *AsyncResult, count)
+	var expected []uint64
+	for i := uint64(0); i < count; i++ {
+		require.NoError(conn.ExecAsync(context.Background(), &Call{Name: "seq", Tuple: []interface{}{i}}, i, replies))
+		expected = append(expected, i)
+	}
+
+	var replied []uint64
+	for i := 0; i < count; i++ {
+		res := <-replies
+		require.NoError(res.Error)
+		replied = append(replied, res.Opaque.(uint64))
+	}
+
+	assert.Equal(expected, replied)
+	mu.Lock()
+	assert.Equal(expected, handled)
+	mu.Unlock()
+}
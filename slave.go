@@ -3,7 +3,11 @@ package tarantool
 import (
 	"bufio"
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,6 +36,19 @@ type Slave struct {
 	next       func() (*Packet, error) // next stores current iterator
 	p          *Packet                 // p stores last packet for Packet method
 	err        error                   // err stores last error for Err method
+
+	store        PositionStore // store keeps VClock, see SetPositionStore
+	saveEvery    int           // saveEvery is the number of packets between checkpoints
+	saveInterval time.Duration // saveInterval is the time between checkpoints
+	unsaved      int           // unsaved is the number of packets since the last checkpoint
+	savedAt      time.Time     // savedAt is the time of the last checkpoint
+	subscribed   bool          // subscribed is set once Resume has subscribed since the position
+	master       VectorClock   // master is the vclock of the master known since subscribe
+	idleTimeout  time.Duration // idleTimeout limits the wait for a packet if it is set
+	filter       *replicationFilter
+
+	heartbeatStop chan struct{} // heartbeatStop is closed to stop sending heartbeat messages
+	heartbeatDone chan struct{} // heartbeatDone is closed once heartbeat messages are not sent any more
 }
 
 // NewSlave instance with tarantool master uri.
@@ -182,7 +199,7 @@ func (s *Slave) Subscribe(lsns ...uint64) (it PacketIterator, err error) {
 	}
 
 	// Start sending heartbeat messages to master
-	go s.heartbeat()
+	s.startHeartbeat()
 
 	return s, nil
 }
@@ -239,6 +256,230 @@ func (s *Slave) LastSnapVClock() (VectorClock, error) {
 	return vc, nil
 }
 
+// SetFilter makes Slave skip the rows received after subscribe which don't match the filter,
+// nil filter passes all the rows. The rows are skipped by the header and the space id of the body
+// without decoding the rest, VClock still follows them. The snapshot rows are not filtered.
+// The names of the spaces are resolved by the master, so SetFilter must be called before subscribe.
+func (s *Slave) SetFilter(filter *ReplicationFilter) error {
+	if filter == nil {
+		s.filter = nil
+		return nil
+	}
+
+	f, err := newReplicationFilter(filter, s.spaceNo)
+	if err != nil {
+		return err
+	}
+	s.filter = f
+	return nil
+}
+
+// spaceNo selects the id of the space by its name from _vspace.
+func (s *Slave) spaceNo(name string) (uint, error) {
+	pp, err := s.newPacket(&Select{Space: ViewSpace, Index: 2, Key: name})
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.send(pp); err != nil {
+		return 0, err
+	}
+	s.c.releasePacket(pp)
+
+	if pp, err = s.receive(); err != nil {
+		return 0, err
+	}
+	defer s.c.releasePacket(pp)
+
+	p := &pp.packet
+	if err = p.UnmarshalBinary(pp.body); err != nil {
+		return 0, err
+	}
+	if p.Result == nil {
+		return 0, ErrBadResult
+	}
+	if p.Result.Error != nil {
+		return 0, p.Result.Error
+	}
+
+	if len(p.Result.Data) == 0 || len(p.Result.Data[0]) == 0 {
+		return 0, NewQueryError(ErrNoSuchSpace, fmt.Sprintf("Space '%s' does not exist", name))
+	}
+	id, err := numberToUint64(p.Result.Data[0][0])
+	if err != nil {
+		return 0, ErrBadResult
+	}
+	return uint(id), nil
+}
+
+// SetPositionStore makes Slave save VClock to the store while iterating the DML requests.
+// The position is saved after every packets or once the interval has passed since the last save,
+// the condition checked first wins. If both are zero, it is saved after each packet.
+// Only the position of the packets already handled is saved, i.e. returned by Next before the current call,
+// so after a restart the last packets may be received again but none is lost.
+func (s *Slave) SetPositionStore(store PositionStore, packets int, interval time.Duration) {
+	s.store = store
+	s.saveEvery = packets
+	s.saveInterval = interval
+	s.unsaved = 0
+	s.savedAt = time.Now()
+}
+
+// SavePosition saves VClock to the position store immediately, e.g. before Close.
+func (s *Slave) SavePosition() error {
+	if s.store == nil {
+		return ErrNoPositionStore
+	}
+	if err := s.store.Save(s.VClock.Clone()); err != nil {
+		return err
+	}
+	s.unsaved = 0
+	s.savedAt = time.Now()
+	return nil
+}
+
+// checkpoint saves VClock if there are new packets and the position is due to be saved.
+func (s *Slave) checkpoint() error {
+	if s.store == nil || s.unsaved == 0 {
+		return nil
+	}
+
+	due := s.saveEvery <= 0 && s.saveInterval <= 0
+	if s.saveEvery > 0 && s.unsaved >= s.saveEvery {
+		due = true
+	}
+	if s.saveInterval > 0 && time.Since(s.savedAt) >= s.saveInterval {
+		due = true
+	}
+	if !due {
+		return nil
+	}
+	return s.SavePosition()
+}
+
+// Resume subscribes for DML requests since the position saved to the store set by SetPositionStore.
+// If nothing is saved yet or the master has no xlogs since the position any more (ErrXlogGap or ErrMissingSnapshot
+// in response to SUBSCRIBE or in place of the first row), Resume joins Replica Set as JoinWithSnap does. The snapshot is received first, then the DML requests
+// since it follow in the same stream, and the position is saved once the snapshot has been handled.
+// The rows with LSN not above the position are skipped, e.g. relayed again by the master.
+// Replica Set and self UUID should be set to subscribe from the saved position, as for Subscribe.
+// Use out chan for asynchronous packet receiving or synchronous PacketIterator otherwise.
+func (s *Slave) Resume(out ...chan *Packet) (it PacketIterator, err error) {
+	return s.resume(s.JoinWithSnap, s.Subscribe, out...)
+}
+
+// resume implements Resume for Slave and AnonSlave, which have their own join and subscribe.
+func (s *Slave) resume(join func(...chan *Packet) (PacketIterator, error), subscribe func(...uint64) (PacketIterator, error), out ...chan *Packet) (it PacketIterator, err error) {
+	if s.store == nil {
+		return nil, ErrNoPositionStore
+	}
+
+	vc, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vc) > 1 {
+		err = s.subscribeFrom(subscribe, vc)
+		if err != nil && !isPositionLost(err) {
+			return nil, err
+		}
+	}
+
+	if err == nil && len(vc) > 1 {
+		// the master accepts SUBSCRIBE and then reports the lost position with the first row
+		xlog := s.next
+		s.next = func() (*Packet, error) {
+			s.next = xlog
+			p, err := xlog()
+			if !isPositionLost(err) {
+				return p, err
+			}
+			s.stopHeartbeat()
+			if err = s.rejoin(join, subscribe); err != nil {
+				return nil, err
+			}
+			return s.next()
+		}
+	} else if err = s.rejoin(join, subscribe); err != nil {
+		return nil, err
+	}
+
+	// no chan means synchronous dml request receiving
+	if s.isEmptyChan(out...) {
+		return s, nil
+	}
+
+	go func(out chan *Packet) {
+		defer close(out)
+		for s.HasNext() {
+			out <- s.Packet()
+		}
+	}(out[0])
+
+	// return nil iterator to avoid concurrent using of the Next method
+	return nil, nil
+}
+
+// rejoin joins Replica Set and subscribes since the snapshot once it has been iterated.
+func (s *Slave) rejoin(join func(...chan *Packet) (PacketIterator, error), subscribe func(...uint64) (PacketIterator, error)) error {
+	s.subscribed = false
+	if _, err := join(); err != nil {
+		return err
+	}
+
+	// the iterator of the snapshot replaces itself with the one of the final data
+	snap := s.next
+	var next func() (*Packet, error)
+	next = func() (*Packet, error) {
+		s.next = snap
+		p, err := snap()
+		snap = s.next
+		if err != io.EOF {
+			s.next = next
+			return p, err
+		}
+		if len(s.VClock) <= 1 {
+			return nil, ErrVectorClock
+		}
+		if err = s.subscribeFrom(subscribe, s.VClock.Clone()); err != nil {
+			return nil, err
+		}
+		if err = s.SavePosition(); err != nil {
+			return nil, err
+		}
+		return s.next()
+	}
+	s.next = next
+	return nil
+}
+
+// subscribeFrom subscribes since the vector clock and follows it instead of the vclock of the master,
+// which may be ahead of the packets received.
+func (s *Slave) subscribeFrom(subscribe func(...uint64) (PacketIterator, error), vc VectorClock) error {
+	if _, err := subscribe(vc[1:]...); err != nil {
+		return err
+	}
+	// the stored vclock may have no room to follow new instances
+	s.VClock = NewVectorClock(vc[1:]...)
+	s.unsaved = 0
+	s.savedAt = time.Now()
+	s.subscribed = true
+	return nil
+}
+
+// isPositionLost checks whether the master has no xlogs since the requested vector clock.
+// Tarantool has no ER_MISSING_REQUEST error code (see const.go): the relay reports the xlogs
+// removed by the garbage collector with ErrXlogGap, and ErrMissingSnapshot means the master
+// has no checkpoint to recover from, so the position can't be served either.
+func isPositionLost(err error) bool {
+	var qerr *QueryError
+	if !errors.As(err, &qerr) {
+		return false
+	}
+	return qerr.Code == ErrXlogGap || qerr.Code == ErrMissingSnapshot
+}
+
 // join send JOIN request.
 func (s *Slave) join() (err error) {
 	pp, err := s.newPacket(&Join{UUID: s.UUID})
@@ -327,10 +568,62 @@ func (s *Slave) subscribe(lsns ...uint64) error {
 	}
 
 	s.VClock = v.VClock
+	s.followMaster(v.VClock, vc)
 
 	return nil
 }
 
+// followMaster keeps the vclock of the master returned by SUBSCRIBE
+// and publishes how far the requested vclock is behind it.
+func (s *Slave) followMaster(master, vc VectorClock) {
+	s.master = master.Clone()
+	for id := 1; id < len(s.master); id++ {
+		var lsn uint64
+		if id < len(vc) {
+			lsn = vc[id]
+		}
+		s.publishLSNLag(uint32(id), lsn)
+	}
+}
+
+// publishLag sets the replication lag counters of PerfCount by the packet received from the master,
+// the LSN lag is set by the rows only.
+func (s *Slave) publishLag(p *Packet, row bool) {
+	perf := s.c.perf
+	if perf.ReplicationLag != nil && !p.Timestamp.IsZero() {
+		perf.ReplicationLag.Set(time.Since(p.Timestamp).Seconds())
+	}
+	if perf.ReplicationLSNLag == nil || !row {
+		return
+	}
+	if p.InstanceID >= uint32(len(s.master)) || s.master[p.InstanceID] < p.LSN {
+		if !s.master.Follow(p.InstanceID, p.LSN) {
+			return
+		}
+	}
+	s.publishLSNLag(p.InstanceID, p.LSN)
+}
+
+func (s *Slave) publishLSNLag(id uint32, lsn uint64) {
+	m := s.c.perf.ReplicationLSNLag
+	if m == nil || id >= uint32(len(s.master)) {
+		return
+	}
+
+	var lag int64
+	if s.master[id] > lsn {
+		lag = int64(s.master[id] - lsn)
+	}
+
+	key := strconv.FormatUint(uint64(id), 10)
+	v, ok := m.Get(key).(*expvar.Int)
+	if !ok {
+		v = new(expvar.Int)
+		m.Set(key, v)
+	}
+	v.Set(lag)
+}
+
 // HasNext implements bufio.Scanner Scan style iterator.
 func (s *Slave) HasNext() bool {
 	s.p, s.err = s.Next()
@@ -442,33 +735,79 @@ func (s *Slave) nextFinalData() (p *Packet, err error) {
 
 // nextXlog iterates new packets (responses on SUBSCRIBE request).
 func (s *Slave) nextXlog() (p *Packet, err error) {
+	// the packets returned before have been handled, so their position can be saved
+	if err = s.checkpoint(); err != nil {
+		return nil, err
+	}
 
+	for {
+		var skip bool
+		if p, skip, err = s.receiveXlog(); err != nil {
+			return nil, err
+		}
+
+		// the master stops relaying after an error, e.g. ErrXlogGap
+		if !skip && p.Request == nil && p.Result.Error != nil {
+			return nil, p.Result.Error
+		}
+
+		// skip heartbeat message
+		if !skip && p.Request == nil && p.Result.ErrorCode == OKCommand {
+			return p, nil
+		}
+
+		// skip the rows received already since Resume, so the position never goes back
+		if s.subscribed && p.InstanceID < uint32(len(s.VClock)) && p.LSN <= s.VClock[p.InstanceID] {
+			continue
+		}
+
+		if !s.VClock.Follow(p.InstanceID, p.LSN) {
+			return nil, ErrVectorClock
+		}
+		s.unsaved++
+
+		if !skip {
+			return p, nil
+		}
+	}
+}
+
+// receiveXlog receives the next packet of the SUBSCRIBE response.
+// The rows not passing the filter are returned with the header decoded only and skip set.
+func (s *Slave) receiveXlog() (p *Packet, skip bool, err error) {
 	pp, err := s.receive()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer s.c.releasePacket(pp)
 
 	p = &Packet{}
-	err = p.UnmarshalBinary(pp.body)
+	body, err := p.UnmarshalBinaryHeader(pp.body)
 	if err != nil {
-		return nil, err
-	}
-	// Tarantool >= 1.7.7 master sends periodic heartbeat messages without body
-	if s.Version() < version1_7_7 && p.Result == nil && p.Request == nil {
-		return nil, ErrBadResult
+		return nil, false, err
 	}
 
-	// skip heartbeat message
-	if p.Request == nil && p.Result.ErrorCode == OKCommand {
-		return p, nil
+	if s.filter != nil && p.Cmd != OKCommand && p.Cmd&ErrorFlag == 0 {
+		pass, err := s.filter.pass(p.Cmd, body)
+		if err != nil {
+			return nil, false, err
+		}
+		if !pass {
+			s.publishLag(p, true)
+			return p, true, nil
+		}
 	}
 
-	if !s.VClock.Follow(p.InstanceID, p.LSN) {
-		return nil, ErrVectorClock
+	if _, err = p.UnmarshalBinaryBody(body); err != nil {
+		return nil, false, err
 	}
+	// Tarantool >= 1.7.7 master sends periodic heartbeat messages without body
+	if s.Version() < version1_7_7 && p.Result == nil && p.Request == nil {
+		return nil, false, ErrBadResult
+	}
+	s.publishLag(p, p.Request != nil)
 
-	return p, nil
+	return p, false, nil
 }
 
 // nextSnap iterates responses on JOIN request.
@@ -543,8 +882,27 @@ func (s *Slave) nextEOF() (*Packet, error) {
 	return nil, io.EOF
 }
 
+// startHeartbeat starts sending heartbeat messages until stopHeartbeat is called.
+func (s *Slave) startHeartbeat() {
+	s.stopHeartbeat()
+	s.heartbeatStop = make(chan struct{})
+	s.heartbeatDone = make(chan struct{})
+	go s.heartbeat(s.heartbeatStop, s.heartbeatDone)
+}
+
+// stopHeartbeat stops sending heartbeat messages, e.g. before another request is sent.
+func (s *Slave) stopHeartbeat() {
+	if s.heartbeatStop == nil {
+		return
+	}
+	close(s.heartbeatStop)
+	<-s.heartbeatDone
+	s.heartbeatStop, s.heartbeatDone = nil, nil
+}
+
 // for Tarantool >= 1.7.0 heartbeat sends encoded vclock to master every second
-func (s *Slave) heartbeat() {
+func (s *Slave) heartbeat(stop, done chan struct{}) {
+	defer close(done)
 	if s.Version() < version1_7_0 {
 		return
 	}
@@ -564,6 +922,8 @@ loop:
 		select {
 		case <-s.c.exit:
 			return
+		case <-stop:
+			return
 		case <-ticker.C:
 			if pp, err = s.newPacket(&VClock{
 				VClock: s.VClock.Clone(),
@@ -623,6 +983,11 @@ func (s *Slave) send(pp *BinaryPacket) (err error) {
 
 // receive new response packet.
 func (s *Slave) receive() (*BinaryPacket, error) {
+	if s.idleTimeout > 0 {
+		if err := s.c.tcpConn.SetReadDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+			return nil, err
+		}
+	}
 	pp := packetPool.Get()
 	_, err := pp.ReadFrom(s.cr)
 	return pp, err
@@ -631,7 +996,7 @@ func (s *Slave) receive() (*BinaryPacket, error) {
 // newPacket compose packet from body.
 func (s *Slave) newPacket(q Query) (pp *BinaryPacket, err error) {
 	pp = packetPool.GetWithID(s.c.nextID())
-	if err = pp.packMsg(q, s.c.packData); err != nil {
+	if err = pp.packMsg(q, s.c.getPackData()); err != nil {
 		s.c.releasePacket(pp)
 		return nil, err
 	}
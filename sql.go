@@ -0,0 +1,281 @@
+package tarantool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ColumnMetaData describes a column of an SQL response or a parameter of a prepared statement.
+type ColumnMetaData struct {
+	Name            string
+	Type            string
+	Collation       string
+	IsNullable      bool
+	IsAutoincrement bool
+	Span            string
+}
+
+// SQLInfo is returned by SQL statements which modify data.
+type SQLInfo struct {
+	RowCount         uint64
+	AutoincrementIDs []uint64
+}
+
+// NamedArg is a named SQL bind parameter, e.g. ":id" or "@id".
+type NamedArg struct {
+	Name  string
+	Value interface{}
+}
+
+// Named returns NamedArg for the bind parameter name.
+// The name is prefixed by ":" unless it already starts with one of ":", "@" or "$".
+func Named(name string, value interface{}) NamedArg {
+	return NamedArg{Name: name, Value: value}
+}
+
+func (arg NamedArg) bindName() string {
+	if strings.HasPrefix(arg.Name, ":") || strings.HasPrefix(arg.Name, "@") || strings.HasPrefix(arg.Name, "$") {
+		return arg.Name
+	}
+	return ":" + arg.Name
+}
+
+func marshalSQLBind(bind []interface{}, o []byte) (_ []byte, err error) {
+	o = msgp.AppendArrayHeader(o, uint32(len(bind)))
+	for _, v := range bind {
+		switch arg := v.(type) {
+		case NamedArg:
+			o = msgp.AppendMapHeader(o, 1)
+			o = msgp.AppendString(o, arg.bindName())
+			if o, err = msgp.AppendIntf(o, arg.Value); err != nil {
+				return o, err
+			}
+		case *NamedArg:
+			o = msgp.AppendMapHeader(o, 1)
+			o = msgp.AppendString(o, arg.bindName())
+			if o, err = msgp.AppendIntf(o, arg.Value); err != nil {
+				return o, err
+			}
+		default:
+			if o, err = msgp.AppendIntf(o, v); err != nil {
+				return o, err
+			}
+		}
+	}
+	return o, nil
+}
+
+func unmarshalSQLBind(data []byte) (bind []interface{}, buf []byte, err error) {
+	var n uint32
+
+	buf = data
+	if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+		return
+	}
+	if n == 0 {
+		return nil, buf, nil
+	}
+
+	bind = make([]interface{}, n)
+	for i := range bind {
+		if msgp.NextType(buf) == msgp.MapType {
+			var l uint32
+			if l, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+				return
+			}
+			if l != 1 {
+				return nil, buf, fmt.Errorf("unexpected named bind parameter map of length %d", l)
+			}
+			arg := NamedArg{}
+			if arg.Name, buf, err = msgp.ReadStringBytes(buf); err != nil {
+				return
+			}
+			if arg.Value, buf, err = readIntfBytes(buf); err != nil {
+				return
+			}
+			bind[i] = arg
+			continue
+		}
+		if bind[i], buf, err = readIntfBytes(buf); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (md *ColumnMetaData) MarshalMsg(b []byte) (o []byte, err error) {
+	n := uint32(2)
+	if md.Collation != "" {
+		n++
+	}
+	if md.IsNullable {
+		n++
+	}
+	if md.IsAutoincrement {
+		n++
+	}
+	if md.Span != "" {
+		n++
+	}
+
+	o = msgp.AppendMapHeader(b, n)
+	o = msgp.AppendUint(o, KeyFieldName)
+	o = msgp.AppendString(o, md.Name)
+	o = msgp.AppendUint(o, KeyFieldType)
+	o = msgp.AppendString(o, md.Type)
+	if md.Collation != "" {
+		o = msgp.AppendUint(o, KeyFieldColl)
+		o = msgp.AppendString(o, md.Collation)
+	}
+	if md.IsNullable {
+		o = msgp.AppendUint(o, KeyFieldIsNullable)
+		o = msgp.AppendBool(o, true)
+	}
+	if md.IsAutoincrement {
+		o = msgp.AppendUint(o, KeyFieldIsAutoincrement)
+		o = msgp.AppendBool(o, true)
+	}
+	if md.Span != "" {
+		o = msgp.AppendUint(o, KeyFieldSpan)
+		o = msgp.AppendString(o, md.Span)
+	}
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (md *ColumnMetaData) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var l uint32
+
+	*md = ColumnMetaData{}
+
+	buf = data
+	if l, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; l > 0; l-- {
+		var cd uint
+
+		if cd, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch cd {
+		case KeyFieldName:
+			md.Name, buf, err = msgp.ReadStringBytes(buf)
+		case KeyFieldType:
+			md.Type, buf, err = msgp.ReadStringBytes(buf)
+		case KeyFieldColl:
+			md.Collation, buf, err = msgp.ReadStringBytes(buf)
+		case KeyFieldIsNullable:
+			md.IsNullable, buf, err = msgp.ReadBoolBytes(buf)
+		case KeyFieldIsAutoincrement:
+			md.IsAutoincrement, buf, err = msgp.ReadBoolBytes(buf)
+		case KeyFieldSpan:
+			if msgp.IsNil(buf) {
+				buf, err = msgp.ReadNilBytes(buf)
+			} else {
+				md.Span, buf, err = msgp.ReadStringBytes(buf)
+			}
+		default:
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func marshalColumnMetaData(md []ColumnMetaData, o []byte) (_ []byte, err error) {
+	o = msgp.AppendArrayHeader(o, uint32(len(md)))
+	for i := range md {
+		if o, err = md[i].MarshalMsg(o); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+func unmarshalColumnMetaData(data []byte) (md []ColumnMetaData, buf []byte, err error) {
+	var n uint32
+
+	buf = data
+	if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+		return
+	}
+
+	md = make([]ColumnMetaData, n)
+	for i := range md {
+		if buf, err = md[i].UnmarshalMsg(buf); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (info *SQLInfo) MarshalMsg(b []byte) (o []byte, err error) {
+	if len(info.AutoincrementIDs) == 0 {
+		o = msgp.AppendMapHeader(b, 1)
+	} else {
+		o = msgp.AppendMapHeader(b, 2)
+	}
+
+	o = msgp.AppendUint(o, KeySQLInfoRowCount)
+	o = msgp.AppendUint64(o, info.RowCount)
+
+	if len(info.AutoincrementIDs) != 0 {
+		o = msgp.AppendUint(o, KeySQLInfoAutoincrementIDs)
+		o = msgp.AppendArrayHeader(o, uint32(len(info.AutoincrementIDs)))
+		for _, id := range info.AutoincrementIDs {
+			o = msgp.AppendUint64(o, id)
+		}
+	}
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (info *SQLInfo) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var l, n uint32
+
+	*info = SQLInfo{}
+
+	buf = data
+	if l, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; l > 0; l-- {
+		var cd uint
+
+		if cd, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch cd {
+		case KeySQLInfoRowCount:
+			if info.RowCount, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		case KeySQLInfoAutoincrementIDs:
+			if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+				return
+			}
+			info.AutoincrementIDs = make([]uint64, n)
+			for i := range info.AutoincrementIDs {
+				if info.AutoincrementIDs[i], buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+					return
+				}
+			}
+		default:
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package tarantool
+
+import (
+	"errors"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Execute runs an SQL statement, either given by its text or by the id of a prepared statement.
+// Bind holds positional parameters and NamedArg values for named ones.
+// It is available since Tarantool >= 2.0.0
+type Execute struct {
+	SQL         string
+	StatementID uint64
+	Bind        []interface{}
+}
+
+var _ Query = (*Execute)(nil)
+
+func (q *Execute) GetCommandID() uint {
+	return ExecuteCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Execute) MarshalMsg(b []byte) (o []byte, err error) {
+	o = b
+	o = msgp.AppendMapHeader(o, 3)
+
+	if q.StatementID != 0 {
+		o = msgp.AppendUint(o, KeyStmtID)
+		o = msgp.AppendUint64(o, q.StatementID)
+	} else {
+		o = msgp.AppendUint(o, KeySQLText)
+		o = msgp.AppendString(o, q.SQL)
+	}
+
+	o = msgp.AppendUint(o, KeySQLBind)
+	if o, err = marshalSQLBind(q.Bind, o); err != nil {
+		return o, err
+	}
+
+	o = msgp.AppendUint(o, KeyOptions)
+	o = msgp.AppendArrayHeader(o, 0)
+
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Execute) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var i uint32
+	var k uint
+
+	q.SQL = ""
+	q.StatementID = 0
+	q.Bind = nil
+
+	buf = data
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeySQLText:
+			if q.SQL, buf, err = msgp.ReadStringBytes(buf); err != nil {
+				return
+			}
+		case KeyStmtID:
+			if q.StatementID, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		case KeySQLBind:
+			if q.Bind, buf, err = unmarshalSQLBind(buf); err != nil {
+				return
+			}
+		default:
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+		}
+	}
+
+	if q.SQL == "" && q.StatementID == 0 {
+		return buf, errors.New("Execute.Unpack: no statement specified")
+	}
+
+	return
+}
@@ -0,0 +1,78 @@
+package tarantool
+
+import (
+	"errors"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Prepare compiles an SQL statement. Result.StatementID holds the id to be passed to Execute.
+// Prepare with a non-zero StatementID and no SQL deallocates the prepared statement.
+// It is available since Tarantool >= 2.3.1
+type Prepare struct {
+	SQL         string
+	StatementID uint64
+}
+
+var _ Query = (*Prepare)(nil)
+
+func (q *Prepare) GetCommandID() uint {
+	return PrepareCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Prepare) MarshalMsg(b []byte) (o []byte, err error) {
+	o = b
+	o = msgp.AppendMapHeader(o, 1)
+
+	if q.SQL == "" && q.StatementID != 0 {
+		o = msgp.AppendUint(o, KeyStmtID)
+		o = msgp.AppendUint64(o, q.StatementID)
+	} else {
+		o = msgp.AppendUint(o, KeySQLText)
+		o = msgp.AppendString(o, q.SQL)
+	}
+
+	return o, nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Prepare) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var i uint32
+	var k uint
+
+	q.SQL = ""
+	q.StatementID = 0
+
+	buf = data
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeySQLText:
+			if q.SQL, buf, err = msgp.ReadStringBytes(buf); err != nil {
+				return
+			}
+		case KeyStmtID:
+			if q.StatementID, buf, err = msgp.ReadUint64Bytes(buf); err != nil {
+				return
+			}
+		default:
+			if buf, err = msgp.Skip(buf); err != nil {
+				return
+			}
+		}
+	}
+
+	if q.SQL == "" && q.StatementID == 0 {
+		return buf, errors.New("Prepare.Unpack: no statement specified")
+	}
+
+	return
+}
@@ -0,0 +1,152 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutePackUnpack(t *testing.T) {
+	q := &Execute{
+		SQL:  "SELECT * FROM tester WHERE id = :id AND name = ?",
+		Bind: []interface{}{Named("id", int64(1)), "Music"},
+	}
+	buf, err := q.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	qa := &Execute{}
+	_, err = qa.UnmarshalMsg(buf)
+	require.NoError(t, err)
+	assert.Equal(t, q.SQL, qa.SQL)
+	assert.Equal(t, []interface{}{NamedArg{":id", int64(1)}, "Music"}, qa.Bind)
+
+	p := &Prepare{StatementID: 42}
+	buf, err = p.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	pa := &Prepare{}
+	_, err = pa.UnmarshalMsg(buf)
+	require.NoError(t, err)
+	assert.Equal(t, p, pa)
+}
+
+func TestResultSQLMarshaling(t *testing.T) {
+	tt := []*Result{
+		{
+			Metadata: []ColumnMetaData{
+				{Name: "ID", Type: "integer", IsAutoincrement: true},
+				{Name: "NAME", Type: "string", Collation: "unicode_ci", IsNullable: true},
+			},
+			Data: [][]interface{}{{int64(1), "First record"}},
+		},
+		{
+			SQLInfo: &SQLInfo{RowCount: 2, AutoincrementIDs: []uint64{3, 4}},
+		},
+		{
+			StatementID:  7,
+			BindCount:    1,
+			BindMetadata: []ColumnMetaData{{Name: "?", Type: "ANY"}},
+			Metadata:     []ColumnMetaData{{Name: "ID", Type: "integer"}},
+			Data:         [][]interface{}{},
+		},
+		// prepared DML statement
+		{
+			StatementID:  8,
+			BindCount:    1,
+			BindMetadata: []ColumnMetaData{{Name: "?", Type: "ANY"}},
+		},
+	}
+
+	for _, res := range tt {
+		buf, err := res.MarshalMsg(nil)
+		require.NoError(t, err)
+
+		actual := &Result{}
+		_, err = actual.UnmarshalMsg(buf)
+		require.NoError(t, err)
+		assert.Equal(t, res, actual)
+	}
+}
+
+func TestExecuteServer(t *testing.T) {
+	require := require.New(t)
+
+	handler := func(ctx context.Context, q Query) *Result {
+		switch q := q.(type) {
+		case *Prepare:
+			return &Result{StatementID: 11, BindCount: uint64(len(q.SQL) % 2)}
+		case *Execute:
+			if q.StatementID != 11 {
+				return &Result{ErrorCode: ErrWrongQueryId, Error: NewQueryError(ErrWrongQueryId, "bad statement")}
+			}
+			return &Result{SQLInfo: &SQLInfo{RowCount: uint64(len(q.Bind))}}
+		}
+		return &Result{}
+	}
+
+	l := newIprotoListener(t, handler)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	res := conn.Exec(context.Background(), &Prepare{SQL: "DELETE FROM t WHERE id = ?"})
+	require.NoError(res.Error)
+	require.EqualValues(11, res.StatementID)
+
+	res = conn.Exec(context.Background(), &Execute{StatementID: res.StatementID, Bind: []interface{}{1}})
+	require.NoError(res.Error)
+	require.Equal(&SQLInfo{RowCount: 1}, res.SQLInfo)
+
+	res = conn.Exec(context.Background(), &Execute{SQL: "DELETE FROM t"})
+	require.Error(res.Error)
+	require.Equal(ErrWrongQueryId, res.ErrorCode)
+}
+
+func TestSQLExecute(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	config := `
+	box.schema.user.grant('guest', 'read,write,execute,create,drop,alter', 'universe', nil, {if_not_exists = true})
+	`
+	box, err := NewBox(config, nil)
+	require.NoError(err)
+	defer box.Close()
+
+	if ver, _ := tntBoxVersion(box); ver < version2_3_1 {
+		t.Skip("requires tarantool >= 2.3.1")
+	}
+
+	conn, err := box.Connect(nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	res := conn.Exec(ctx, &Execute{SQL: "CREATE TABLE tester (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)"})
+	require.NoError(res.Error)
+
+	res = conn.Exec(ctx, &Execute{
+		SQL:  "INSERT INTO tester (id, name) VALUES (NULL, :name), (NULL, ?)",
+		Bind: []interface{}{Named("name", "First record"), "Music"},
+	})
+	require.NoError(res.Error)
+	require.NotNil(res.SQLInfo)
+	assert.EqualValues(2, res.SQLInfo.RowCount)
+	assert.Equal([]uint64{1, 2}, res.SQLInfo.AutoincrementIDs)
+
+	res = conn.Exec(ctx, &Prepare{SQL: "SELECT id, name FROM tester WHERE id > ?"})
+	require.NoError(res.Error)
+	require.NotZero(res.StatementID)
+	assert.EqualValues(1, res.BindCount)
+
+	res = conn.Exec(ctx, &Execute{StatementID: res.StatementID, Bind: []interface{}{1}})
+	require.NoError(res.Error)
+	require.Len(res.Metadata, 2)
+	assert.Equal("ID", res.Metadata[0].Name)
+	assert.Equal("NAME", res.Metadata[1].Name)
+	assert.Equal([][]interface{}{{int64(2), "Music"}}, res.Data)
+}
@@ -0,0 +1,57 @@
+package tarantool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Stream is a sequence of requests executed by Tarantool one by one in the order they are sent.
+// Streams are used for interactive transactions: Begin, any number of queries, then Commit or Rollback.
+// Streams are available since Tarantool >= 2.10.0
+type Stream struct {
+	ID   uint64
+	conn *Connection
+}
+
+type streamOption struct {
+	streamID uint64
+}
+
+func (o *streamOption) apply(r *request) {
+	r.streamID = o.streamID
+}
+
+// StreamExecOption executes a query within the stream with the given id.
+func StreamExecOption(streamID uint64) ExecOption {
+	return &streamOption{streamID}
+}
+
+// NewStream returns a new stream bound to the connection.
+func (conn *Connection) NewStream() *Stream {
+	return &Stream{
+		ID:   atomic.AddUint64(&conn.streamID, 1),
+		conn: conn,
+	}
+}
+
+// Exec executes the query within the stream.
+func (s *Stream) Exec(ctx context.Context, q Query, options ...ExecOption) *Result {
+	return s.conn.Exec(ctx, q, append(options, StreamExecOption(s.ID))...)
+}
+
+// Begin starts a transaction with the isolation level (TxnIsolation*) and timeout.
+// Zero timeout means the server default (box.cfg.txn_timeout).
+func (s *Stream) Begin(ctx context.Context, isolation uint, timeout time.Duration) error {
+	return s.Exec(ctx, &Begin{Isolation: isolation, Timeout: timeout}).Error
+}
+
+// Commit the stream transaction.
+func (s *Stream) Commit(ctx context.Context) error {
+	return s.Exec(ctx, &Commit{}).Error
+}
+
+// Rollback the stream transaction.
+func (s *Stream) Rollback(ctx context.Context) error {
+	return s.Exec(ctx, &Rollback{}).Error
+}
@@ -0,0 +1,77 @@
+package tarantool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeginPackUnpack(t *testing.T) {
+	q := &Begin{Isolation: TxnIsolationReadCommitted, Timeout: 1500 * time.Millisecond}
+	buf, err := q.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	qa := &Begin{}
+	_, err = qa.UnmarshalMsg(buf)
+	require.NoError(t, err)
+	assert.Equal(t, q, qa)
+}
+
+func TestStreamServer(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	type call struct {
+		streamID uint64
+		cmd      uint
+	}
+	var mu sync.Mutex
+	var calls []call
+
+	handler := func(ctx context.Context, q Query) *Result {
+		streamID, _ := StreamIDFromContext(ctx)
+		if _, ok := q.(*Select); ok && streamID == 0 {
+			// schema pulling
+			return &Result{}
+		}
+		mu.Lock()
+		calls = append(calls, call{streamID, q.GetCommandID()})
+		mu.Unlock()
+		if b, ok := q.(*Begin); ok && b.Isolation != TxnIsolationReadCommitted {
+			return &Result{ErrorCode: ErrUnsupported, Error: ErrNotSupported}
+		}
+		return &Result{}
+	}
+
+	l := newIprotoListener(t, handler)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	s1 := conn.NewStream()
+	s2 := conn.NewStream()
+	assert.NotEqual(s1.ID, s2.ID)
+
+	require.NoError(s1.Begin(ctx, TxnIsolationReadCommitted, time.Second))
+	res := s1.Exec(ctx, &Insert{Space: 1, Tuple: []interface{}{1}})
+	require.NoError(res.Error)
+	require.NoError(s1.Commit(ctx))
+
+	require.Error(s2.Begin(ctx, TxnIsolationBestEffort, 0))
+	require.NoError(s2.Rollback(ctx))
+
+	assert.Equal([]call{
+		{s1.ID, BeginCommand},
+		{s1.ID, InsertCommand},
+		{s1.ID, CommitCommand},
+		{s2.ID, BeginCommand},
+		{s2.ID, RollbackCommand},
+	}, calls)
+}
@@ -0,0 +1,453 @@
+package tarantool
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ErrBadResultDest is returned if the destination passed to ResultIntoExecOption has unsupported type.
+var ErrBadResultDest = errors.New("result destination must be a non-nil pointer to a struct or to a slice")
+
+// structField is the exported struct field mapped to the tuple field.
+type structField struct {
+	index int
+	name  string
+}
+
+// structInfo maps struct fields to tuple fields.
+// Tuple fields go in the order of struct fields, the field name is used
+// to match SQL columns and map keys. The name is set with the tag:
+//
+//	type User struct {
+//		ID    uint64 `tarantool:"id"`
+//		Name  string `tarantool:"name"`
+//		Cache []byte `tarantool:"-"` // not a tuple field
+//	}
+//
+// Untagged fields are named after the struct field.
+type structInfo struct {
+	fields []structField
+	byName map[string]int // lowercase name => position in fields
+}
+
+var structInfoCache sync.Map // reflect.Type => *structInfo
+
+var (
+	extensionType   = reflect.TypeOf((*msgp.Extension)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*msgp.Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+func getStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{byName: make(map[string]int)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("tarantool"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		info.byName[strings.ToLower(name)] = len(info.fields)
+		info.fields = append(info.fields, structField{index: i, name: name})
+	}
+
+	structInfoCache.Store(t, info)
+	return info
+}
+
+// field returns the struct field by the tuple field name, case-insensitive.
+func (info *structInfo) field(name string) (structField, bool) {
+	i, ok := info.byName[strings.ToLower(name)]
+	if !ok {
+		return structField{}, false
+	}
+	return info.fields[i], true
+}
+
+// decodeTuples decodes the array of tuples into dest, which is a pointer to a slice of structs
+// (or pointers to them) or a pointer to a single struct receiving the first tuple.
+// If names are given, tuple fields are matched to struct fields by names instead of positions.
+func decodeTuples(data []byte, dest interface{}, names []string) (buf []byte, err error) {
+	var n uint32
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return data, ErrBadResultDest
+	}
+	dv = dv.Elem()
+
+	buf = data
+	if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+		return
+	}
+
+	switch dv.Kind() {
+	case reflect.Slice:
+		if dv.IsNil() || dv.Cap() < int(n) {
+			dv.Set(reflect.MakeSlice(dv.Type(), int(n), int(n)))
+		} else {
+			dv.SetLen(int(n))
+		}
+		// the elements left from the previous use are not merged with the tuples
+		zero := reflect.Zero(dv.Type().Elem())
+		for i := 0; i < int(n); i++ {
+			dv.Index(i).Set(zero)
+			if buf, err = decodeTuple(buf, dv.Index(i), names); err != nil {
+				return
+			}
+		}
+	case reflect.Struct:
+		// the struct is zeroed if there is no tuple
+		dv.Set(reflect.Zero(dv.Type()))
+		for i := 0; i < int(n); i++ {
+			if i == 0 {
+				buf, err = decodeTuple(buf, dv, names)
+			} else {
+				buf, err = msgp.Skip(buf)
+			}
+			if err != nil {
+				return
+			}
+		}
+	default:
+		return data, ErrBadResultDest
+	}
+	return
+}
+
+func decodeTuple(data []byte, v reflect.Value, names []string) (buf []byte, err error) {
+	var n uint32
+
+	if v.Kind() == reflect.Ptr {
+		if msgp.IsNil(data) {
+			v.Set(reflect.Zero(v.Type()))
+			return msgp.ReadNilBytes(data)
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if len(names) == 0 || v.Kind() != reflect.Struct || msgp.NextType(data) != msgp.ArrayType {
+		return decodeValue(data, v)
+	}
+
+	info := getStructInfo(v.Type())
+	v.Set(reflect.Zero(v.Type()))
+
+	buf = data
+	if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+		return
+	}
+	for i := 0; i < int(n); i++ {
+		f, ok := structField{}, false
+		if i < len(names) {
+			f, ok = info.field(names[i])
+		}
+		if ok {
+			buf, err = decodeValue(buf, v.Field(f.index))
+		} else {
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func decodeError(data []byte, v reflect.Value) error {
+	return fmt.Errorf("can't decode msgpack %s into %s", msgp.NextType(data), v.Type())
+}
+
+// decodeValue decodes the msgpack value into v without intermediate interface{} values.
+func decodeValue(data []byte, v reflect.Value) (buf []byte, err error) {
+	buf = data
+
+	if msgp.IsNil(buf) {
+		v.Set(reflect.Zero(v.Type()))
+		return msgp.ReadNilBytes(buf)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(buf, v.Elem())
+	}
+
+	if v.Type() == timeType {
+		dt := &Datetime{}
+		if buf, err = msgp.ReadExtensionBytes(buf, dt); err != nil {
+			return data, decodeError(data, v)
+		}
+		v.Set(reflect.ValueOf(dt.Time))
+		return buf, nil
+	}
+
+	if v.CanAddr() {
+		switch pv := v.Addr(); {
+		case pv.Type().Implements(extensionType):
+			return msgp.ReadExtensionBytes(buf, pv.Interface().(msgp.Extension))
+		case pv.Type().Implements(unmarshalerType):
+			return pv.Interface().(msgp.Unmarshaler).UnmarshalMsg(buf)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		if b, buf, err = msgp.ReadBoolBytes(buf); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, buf, err = msgp.ReadInt64Bytes(buf); err == nil {
+			if v.OverflowInt(i) {
+				return data, fmt.Errorf("value %d overflows %s", i, v.Type())
+			}
+			v.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, buf, err = msgp.ReadUint64Bytes(buf); err == nil {
+			if v.OverflowUint(u) {
+				return data, fmt.Errorf("value %d overflows %s", u, v.Type())
+			}
+			v.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch msgp.NextType(buf) {
+		case msgp.Float32Type:
+			var f32 float32
+			f32, buf, err = msgp.ReadFloat32Bytes(buf)
+			f = float64(f32)
+		case msgp.IntType:
+			var i int64
+			i, buf, err = msgp.ReadInt64Bytes(buf)
+			f = float64(i)
+		case msgp.UintType:
+			var u uint64
+			u, buf, err = msgp.ReadUint64Bytes(buf)
+			f = float64(u)
+		default:
+			f, buf, err = msgp.ReadFloat64Bytes(buf)
+		}
+		if err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.String:
+		var s []byte
+		if msgp.NextType(buf) == msgp.BinType {
+			s, buf, err = msgp.ReadBytesZC(buf)
+		} else {
+			s, buf, err = msgp.ReadStringZC(buf)
+		}
+		if err == nil {
+			v.SetString(string(s))
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && msgp.NextType(buf) != msgp.ArrayType {
+			var b []byte
+			if msgp.NextType(buf) == msgp.StrType {
+				b, buf, err = msgp.ReadStringZC(buf)
+			} else {
+				b, buf, err = msgp.ReadBytesZC(buf)
+			}
+			if err == nil {
+				v.SetBytes(append([]byte(nil), b...))
+			}
+			break
+		}
+
+		var n uint32
+		if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+			return data, decodeError(data, v)
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n), int(n)))
+		for i := 0; i < int(n); i++ {
+			if buf, err = decodeValue(buf, v.Index(i)); err != nil {
+				return
+			}
+		}
+	case reflect.Array:
+		var n uint32
+		if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+			return data, decodeError(data, v)
+		}
+		v.Set(reflect.Zero(v.Type()))
+		for i := 0; i < int(n); i++ {
+			if i < v.Len() {
+				buf, err = decodeValue(buf, v.Index(i))
+			} else {
+				buf, err = msgp.Skip(buf)
+			}
+			if err != nil {
+				return
+			}
+		}
+	case reflect.Map:
+		var n uint32
+		if n, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+			return data, decodeError(data, v)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), int(n))
+		for i := 0; i < int(n); i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if buf, err = decodeValue(buf, key); err != nil {
+				return
+			}
+			val := reflect.New(v.Type().Elem()).Elem()
+			if buf, err = decodeValue(buf, val); err != nil {
+				return
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		return decodeStruct(buf, v)
+	case reflect.Interface:
+		var i interface{}
+		if i, buf, err = readIntfBytes(buf); err != nil {
+			return
+		}
+		iv := reflect.ValueOf(i)
+		if !iv.Type().AssignableTo(v.Type()) {
+			return data, decodeError(data, v)
+		}
+		v.Set(iv)
+	default:
+		return data, decodeError(data, v)
+	}
+
+	if err != nil {
+		return data, decodeError(data, v)
+	}
+	return buf, nil
+}
+
+// decodeStruct decodes the tuple (an array) or the map keyed by field names into the struct.
+func decodeStruct(data []byte, v reflect.Value) (buf []byte, err error) {
+	var n uint32
+
+	info := getStructInfo(v.Type())
+	v.Set(reflect.Zero(v.Type()))
+
+	buf = data
+	switch msgp.NextType(buf) {
+	case msgp.ArrayType:
+		if n, buf, err = msgp.ReadArrayHeaderBytes(buf); err != nil {
+			return
+		}
+		for i := 0; i < int(n); i++ {
+			if i < len(info.fields) {
+				buf, err = decodeValue(buf, v.Field(info.fields[i].index))
+			} else {
+				buf, err = msgp.Skip(buf)
+			}
+			if err != nil {
+				return
+			}
+		}
+	case msgp.MapType:
+		var key []byte
+		if n, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+			return
+		}
+		for i := 0; i < int(n); i++ {
+			if key, buf, err = msgp.ReadMapKeyZC(buf); err != nil {
+				return
+			}
+			if f, ok := info.field(string(key)); ok {
+				buf, err = decodeValue(buf, v.Field(f.index))
+			} else {
+				buf, err = msgp.Skip(buf)
+			}
+			if err != nil {
+				return
+			}
+		}
+	default:
+		return data, decodeError(data, v)
+	}
+	return
+}
+
+// StructToTuple returns the tuple made of the struct fields to be used in Insert, Replace etc.
+// Fields go in the same order as they are decoded by ResultIntoExecOption.
+func StructToTuple(s interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't make tuple of %T", s)
+	}
+	return structToTuple(v), nil
+}
+
+func structToTuple(v reflect.Value) []interface{} {
+	info := getStructInfo(v.Type())
+	tuple := make([]interface{}, len(info.fields))
+	for i, f := range info.fields {
+		tuple[i] = encodeValue(v.Field(f.index))
+	}
+	return tuple
+}
+
+// encodeValue turns nested structs into tuples, the rest is left to msgp.AppendIntf.
+func encodeValue(v reflect.Value) interface{} {
+	// the extension types implement msgp.Extension by pointer, e.g. UUID is an array
+	if v.Kind() != reflect.Ptr && reflect.PtrTo(v.Type()).Implements(extensionType) {
+		pv := reflect.New(v.Type())
+		pv.Elem().Set(v)
+		return pv.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type().Implements(extensionType) || v.Elem().Kind() != reflect.Struct {
+			return v.Interface()
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return NewDatetime(v.Interface().(time.Time))
+		}
+		return structToTuple(v)
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		arr := make([]interface{}, v.Len())
+		for i := range arr {
+			arr[i] = encodeValue(v.Index(i))
+		}
+		return arr
+	}
+	return v.Interface()
+}
@@ -0,0 +1,183 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City   string
+	Street string
+}
+
+type testUser struct {
+	ID       uint64            `tarantool:"id"`
+	Name     string            `tarantool:"name"`
+	Age      int               `tarantool:"age"`
+	Balance  *Decimal          `tarantool:"balance"`
+	Tags     []string          `tarantool:"tags"`
+	Address  testAddress       `tarantool:"address"`
+	Attrs    map[string]string `tarantool:"attrs"`
+	Created  time.Time         `tarantool:"created"`
+	Comment  *string           `tarantool:"comment"`
+	internal int
+	Cache    []byte `tarantool:"-"`
+}
+
+func newTestUser() *testUser {
+	return &testUser{
+		ID:      1,
+		Name:    "Alice",
+		Age:     33,
+		Balance: NewDecimal(10050, 2),
+		Tags:    []string{"admin"},
+		Address: testAddress{City: "Moscow", Street: "Tverskaya"},
+		Attrs:   map[string]string{"lang": "en"},
+		Created: time.Unix(1643630400, 0).UTC(),
+	}
+}
+
+func TestStructToTuple(t *testing.T) {
+	require := require.New(t)
+
+	u := newTestUser()
+	u.Cache = []byte("cached")
+
+	tuple, err := StructToTuple(u)
+	require.NoError(err)
+	require.Len(tuple, 9)
+	require.Equal(uint64(1), tuple[0])
+	require.Equal([]interface{}{"Moscow", "Tverskaya"}, tuple[5])
+	require.Equal(NewDatetime(u.Created), tuple[7])
+	require.Equal((*string)(nil), tuple[8])
+
+	_, err = StructToTuple(42)
+	require.Error(err)
+}
+
+func TestResultIntoStructs(t *testing.T) {
+	require := require.New(t)
+
+	tuple, err := StructToTuple(newTestUser())
+	require.NoError(err)
+
+	buf, err := (&Result{Data: [][]interface{}{tuple, tuple}}).MarshalMsg(nil)
+	require.NoError(err)
+
+	var users []testUser
+	res := &Result{dest: &users}
+	_, err = res.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Nil(res.Data)
+	require.Len(users, 2)
+	require.Equal(*newTestUser(), users[0])
+
+	var ptrs []*testUser
+	_, err = (&Result{dest: &ptrs}).UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Len(ptrs, 2)
+	require.Equal(newTestUser(), ptrs[1])
+
+	var user testUser
+	_, err = (&Result{dest: &user}).UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal(*newTestUser(), user)
+
+	// the destinations are reused without the values of the previous results
+	short, err := (&Result{Data: [][]interface{}{{uint64(3)}}}).MarshalMsg(nil)
+	require.NoError(err)
+	_, err = (&Result{dest: &users}).UnmarshalMsg(short)
+	require.NoError(err)
+	require.Equal([]testUser{{ID: 3}}, users)
+
+	empty, err := (&Result{Data: [][]interface{}{}}).MarshalMsg(nil)
+	require.NoError(err)
+	_, err = (&Result{dest: &user}).UnmarshalMsg(empty)
+	require.NoError(err)
+	require.Equal(testUser{}, user)
+
+	var wrong []struct{ ID string }
+	_, err = (&Result{dest: &wrong}).UnmarshalMsg(buf)
+	require.Error(err)
+
+	_, err = (&Result{dest: users}).UnmarshalMsg(buf)
+	require.Equal(ErrBadResultDest, err)
+}
+
+func TestStructExtensionValues(t *testing.T) {
+	require := require.New(t)
+
+	type row struct {
+		ID      UUID
+		Balance Decimal
+		Seq     uint64
+	}
+
+	id, err := ParseUUID("c8f0fa1f-da29-438c-a040-393f1126ad39")
+	require.NoError(err)
+	r := row{ID: *id, Balance: *NewDecimal(-12345, 3), Seq: 1}
+
+	tuple, err := StructToTuple(&r)
+	require.NoError(err)
+	require.Equal([]interface{}{id, NewDecimal(-12345, 3), uint64(1)}, tuple)
+
+	buf, err := (&Result{Data: [][]interface{}{tuple}}).MarshalMsg(nil)
+	require.NoError(err)
+
+	var rows []row
+	_, err = (&Result{dest: &rows}).UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal([]row{r}, rows)
+}
+
+func TestResultIntoStructsBySQLNames(t *testing.T) {
+	require := require.New(t)
+
+	res := &Result{
+		Metadata: []ColumnMetaData{{Name: "NAME", Type: "string"}, {Name: "UNKNOWN", Type: "any"}, {Name: "ID", Type: "unsigned"}},
+		Data:     [][]interface{}{{"Bob", "skipped", uint64(2)}},
+	}
+	buf, err := res.MarshalMsg(nil)
+	require.NoError(err)
+
+	var users []testUser
+	_, err = (&Result{dest: &users}).UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal([]testUser{{ID: 2, Name: "Bob"}}, users)
+}
+
+func TestExecResultInto(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	tuple, err := StructToTuple(newTestUser())
+	require.NoError(err)
+
+	l := newIprotoListener(t, func(_ context.Context, q Query) *Result {
+		if sel, ok := q.(*Select); ok && sel.Space == uint(512) {
+			return &Result{Data: [][]interface{}{tuple}}
+		}
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	var users []*testUser
+	res := conn.Exec(context.Background(), &Select{Space: 512, Key: uint64(1)}, ResultIntoExecOption(&users))
+	require.NoError(res.Error)
+	assert.Nil(res.Data)
+	require.Len(users, 1)
+	assert.Equal(newTestUser(), users[0])
+
+	// the option applies to the single request only
+	res = conn.Exec(context.Background(), &Select{Space: 512, Key: uint64(1)})
+	require.NoError(res.Error)
+	assert.Len(res.Data, 1)
+}
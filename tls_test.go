@@ -0,0 +1,100 @@
+package tarantool
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTLSConfigs returns the configs of the server with a self-signed certificate
+// and of the client trusting it.
+func newTestTLSConfigs(t *testing.T) (server *tls.Config, client *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tarantool"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool}
+	return
+}
+
+func TestConnectTLS(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	serverConfig, clientConfig := newTestTLSConfigs(t)
+
+	l := newIprotoListenerWithOptions(t, func(_ context.Context, q Query) *Result {
+		if _, ok := q.(*Call); ok {
+			return &Result{Data: [][]interface{}{{"secure"}}}
+		}
+		return &Result{}
+	}, &IprotoServerOptions{TLSConfig: serverConfig})
+	defer l.Close()
+
+	for _, dsn := range []string{"tls://" + l.Addr(), "ssl://" + l.Addr(), l.Addr()} {
+		conn, err := Connect(dsn, &Options{TLSConfig: clientConfig})
+		require.NoError(err, dsn)
+
+		res := conn.Exec(context.Background(), &Call{Name: "whoami"})
+		require.NoError(res.Error, dsn)
+		assert.Equal([][]interface{}{{"secure"}}, res.Data, dsn)
+
+		_, ok := conn.tcpConn.(*tls.Conn)
+		assert.True(ok, dsn)
+		conn.Close()
+	}
+
+	tnt := New("tls://"+l.Addr(), &Options{TLSConfig: clientConfig})
+	defer tnt.Close()
+	conn, err := tnt.Connect()
+	require.NoError(err)
+	require.NoError(conn.Exec(context.Background(), &Ping{}).Error)
+
+	// the scheme is kept on reconnect
+	conn.Close()
+	conn, err = tnt.Connect()
+	require.NoError(err)
+	require.NoError(conn.Exec(context.Background(), &Ping{}).Error)
+	_, ok := conn.tcpConn.(*tls.Conn)
+	assert.True(ok)
+
+	// the certificate is not trusted
+	_, err = Connect("tls://"+l.Addr(), &Options{ConnectTimeout: 100 * time.Millisecond})
+	assert.Error(err)
+
+	// plain connection gets no greeting
+	_, err = Connect(l.Addr(), &Options{ConnectTimeout: 100 * time.Millisecond})
+	assert.Error(err)
+}
@@ -22,6 +22,9 @@ type request struct {
 	packet     *BinaryPacket
 	startedAt  time.Time
 	resultMode resultUnmarshalMode
+	resultDest interface{}
+	streamID   uint64
+	schemaless bool // don't send the schema id, e.g. on schema reload
 }
 
 type QueryCompleteFn func(interface{}, time.Duration)
@@ -41,6 +44,13 @@ type PerfCount struct {
 	NetPacketsOut *expvar.Int
 	QueryTimeouts *expvar.Int
 	QueryComplete QueryCompleteFn
+
+	// ReplicationLag is set by Slave to the delay of the last packet from the master in seconds,
+	// heartbeats included. It is measured against the local clock, so the clocks are expected to be in sync.
+	ReplicationLag *expvar.Float
+	// ReplicationLSNLag holds *expvar.Int per instance id set by Slave to the number of LSNs
+	// it is behind the vclock of the master, which is known since subscribe.
+	ReplicationLSNLag *expvar.Map
 }
 
 // ReplicaSet is used to store params of the Replica Set.
@@ -2,3 +2,42 @@ package tarantool
 
 type Bytes []byte
 type Tuple []interface{}
+
+// SpaceField describes a field of the space format, see box.space.<name>:format().
+type SpaceField struct {
+	Name       string
+	Type       string
+	IsNullable bool
+}
+
+func parseSpaceFormat(format []interface{}) []SpaceField {
+	fields := make([]SpaceField, 0, len(format))
+	for _, f := range format {
+		var field SpaceField
+
+		// keep positions of the fields even if a description is unknown
+		descr, _ := f.(map[string]interface{})
+		field.Name, _ = descr["name"].(string)
+		field.Type, _ = descr["type"].(string)
+		field.IsNullable, _ = descr["is_nullable"].(bool)
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// TupleToMap returns the tuple as a map keyed by field names of the format.
+// Fields beyond the format are omitted, missing ones are nil.
+func TupleToMap(tuple []interface{}, format []SpaceField) map[string]interface{} {
+	m := make(map[string]interface{}, len(format))
+	for i, f := range format {
+		if f.Name == "" {
+			continue
+		}
+		if i < len(tuple) {
+			m[f.Name] = tuple[i]
+		} else {
+			m[f.Name] = nil
+		}
+	}
+	return m
+}
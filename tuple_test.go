@@ -0,0 +1,113 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSpaceFormat = []interface{}{
+	map[string]interface{}{"name": "id", "type": "unsigned"},
+	map[string]interface{}{"name": "name", "type": "string"},
+	map[string]interface{}{"name": "age", "type": "integer", "is_nullable": true},
+}
+
+func TestTupleToMap(t *testing.T) {
+	assert := assert.New(t)
+
+	format := parseSpaceFormat(testSpaceFormat)
+	assert.Equal([]SpaceField{
+		{Name: "id", Type: "unsigned"},
+		{Name: "name", Type: "string"},
+		{Name: "age", Type: "integer", IsNullable: true},
+	}, format)
+
+	assert.Equal(map[string]interface{}{"id": uint64(1), "name": "Alice", "age": nil},
+		TupleToMap([]interface{}{uint64(1), "Alice"}, format))
+	assert.Equal(map[string]interface{}{"id": uint64(1), "name": "Alice", "age": int64(33)},
+		TupleToMap([]interface{}{uint64(1), "Alice", int64(33), "extra"}, format))
+}
+
+func TestUpdateOperatorFieldNames(t *testing.T) {
+	require := require.New(t)
+
+	data := newPackData(nil)
+	data.spaceMap["tester"] = 512
+	data.setSpaceFormat(512, parseSpaceFormat(testSpaceFormat))
+
+	set := []Operator{
+		&OpAssign{FieldName: "name", Argument: "Bob"},
+		&OpAdd{FieldName: "age", Argument: 1},
+		&OpSub{Field: 0, Argument: 1},
+		&OpSplice{FieldName: "[2].path", Position: 1, Offset: 1, Argument: "x"},
+	}
+
+	buf, err := (&Update{Space: "tester", Key: 1, Set: set}).packMsg(data, nil)
+	require.NoError(err)
+
+	var q Update
+	_, err = q.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal(&OpAssign{Field: 1, Argument: "Bob"}, q.Set[0])
+	require.Equal(&OpAdd{Field: 2, Argument: 1}, q.Set[1])
+	require.Equal(&OpSub{Field: 0, Argument: 1}, q.Set[2])
+	require.Equal(&OpSplice{FieldName: "[2].path", Position: 1, Offset: 1, Argument: "x"}, q.Set[3])
+
+	buf, err = (&Upsert{Space: 512, Tuple: []interface{}{1}, Set: set[:1]}).packMsg(data, nil)
+	require.NoError(err)
+
+	var u Upsert
+	_, err = u.UnmarshalMsg(buf)
+	require.NoError(err)
+	require.Equal(&OpAssign{Field: 1, Argument: "Bob"}, u.Set[0])
+}
+
+func TestConnectionSpaceFormat(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	updates := make(chan *Update, 1)
+	l := newIprotoListener(t, func(_ context.Context, q Query) *Result {
+		switch q := q.(type) {
+		case *Select:
+			if q.Space == uint(ViewSpace) {
+				return &Result{Data: [][]interface{}{
+					{uint64(512), uint64(1), "tester", "memtx", uint64(0), map[string]interface{}{}, testSpaceFormat},
+				}}
+			}
+		case *Update:
+			updates <- q
+			return &Result{Data: [][]interface{}{{uint64(1), "Bob", int64(33)}}}
+		}
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	format, ok := conn.GetSpaceFormat("tester")
+	require.True(ok)
+	assert.Len(format, 3)
+
+	_, ok = conn.GetSpaceFormat(513)
+	assert.False(ok)
+
+	res := conn.Exec(context.Background(), &Update{
+		Space: "tester",
+		Key:   uint64(1),
+		Set:   []Operator{&OpAssign{FieldName: "name", Argument: "Bob"}},
+	})
+	require.NoError(res.Error)
+	assert.Equal(&OpAssign{Field: 1, Argument: "Bob"}, (<-updates).Set[0])
+
+	maps, err := conn.TuplesToMaps("tester", res.Data)
+	require.NoError(err)
+	assert.Equal([]map[string]interface{}{{"id": int64(1), "name": "Bob", "age": int64(33)}}, maps)
+
+	_, err = conn.TuplesToMaps(513, res.Data)
+	assert.Error(err)
+}
@@ -47,7 +47,7 @@ func (q *Update) packMsg(data *packData, b []byte) (o []byte, err error) {
 	o = msgp.AppendUint(o, KeyTuple)
 	o = msgp.AppendArrayHeader(o, uint32(len(q.Set)))
 	for _, op := range q.Set {
-		if o, err = marshalOperator(op, o); err != nil {
+		if o, err = marshalOperator(op, q.Space, data, o); err != nil {
 			return o, err
 		}
 	}
@@ -89,7 +89,7 @@ func (q *Update) UnmarshalMsg(data []byte) (buf []byte, err error) {
 				return
 			}
 		case KeyKey:
-			t, buf, err = msgp.ReadIntfBytes(buf)
+			t, buf, err = readIntfBytes(buf)
 			if err != nil {
 				return
 			}
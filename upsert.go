@@ -34,7 +34,7 @@ func (q *Upsert) packMsg(data *packData, b []byte) (o []byte, err error) {
 	o = msgp.AppendUint(o, KeyDefTuple)
 	o = msgp.AppendArrayHeader(o, uint32(len(q.Set)))
 	for _, op := range q.Set {
-		if o, err = marshalOperator(op, o); err != nil {
+		if o, err = marshalOperator(op, q.Space, data, o); err != nil {
 			return o, err
 		}
 	}
@@ -71,7 +71,7 @@ func (q *Upsert) UnmarshalMsg(data []byte) (buf []byte, err error) {
 				return
 			}
 		case KeyTuple:
-			t, buf, err = msgp.ReadIntfBytes(buf)
+			t, buf, err = readIntfBytes(buf)
 			if err != nil {
 				return
 			}
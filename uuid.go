@@ -0,0 +1,83 @@
+package tarantool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ErrBadUUID is returned by ParseUUID and by decoding of malformed MP_UUID.
+var ErrBadUUID = errors.New("invalid uuid")
+
+// UUID is Tarantool uuid (MP_UUID).
+// Pass *UUID in tuples and keys, decoded values are *UUID as well.
+// It is available since Tarantool >= 2.4.1
+type UUID [16]byte
+
+var _ msgp.Extension = (*UUID)(nil)
+
+// ParseUUID parses the canonical form, e.g. "c8f0fa1f-da29-438c-a040-393f1126ad39".
+func ParseUUID(s string) (*UUID, error) {
+	var u UUID
+
+	if len(s) != UUIDStrLength || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return nil, ErrBadUUID
+	}
+
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err := hex.Decode(u[:], src); err != nil {
+		return nil, ErrBadUUID
+	}
+	return &u, nil
+}
+
+func (u *UUID) String() string {
+	var b [UUIDStrLength]byte
+
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// randomUUID returns a version 4 uuid, e.g. for the greeting of a server.
+func randomUUID() string {
+	var u UUID
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u.String()
+}
+
+// ExtensionType implements msgp.Extension
+func (u *UUID) ExtensionType() int8 {
+	return ExtUUID
+}
+
+// Len implements msgp.Extension
+func (u *UUID) Len() int {
+	return len(u)
+}
+
+// MarshalBinaryTo implements msgp.Extension
+func (u *UUID) MarshalBinaryTo(b []byte) error {
+	copy(b, u[:])
+	return nil
+}
+
+// UnmarshalBinary implements msgp.Extension
+func (u *UUID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(u) {
+		return ErrBadUUID
+	}
+	copy(u[:], data)
+	return nil
+}
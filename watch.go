@@ -0,0 +1,144 @@
+package tarantool
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Watch subscribes to the box.broadcast key. The server doesn't reply to it,
+// the key value is delivered by Event instead.
+// Watch is also sent to acknowledge the received Event.
+// It is available since Tarantool >= 2.10.0
+type Watch struct {
+	Key string
+}
+
+var _ Query = (*Watch)(nil)
+
+func (q *Watch) GetCommandID() uint {
+	return WatchCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Watch) MarshalMsg(b []byte) (o []byte, err error) {
+	return marshalEventKey(q.Key, b), nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Watch) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	q.Key, buf, err = unmarshalEventKey(data)
+	return
+}
+
+// Unwatch unsubscribes from the box.broadcast key. The server doesn't reply to it.
+// It is available since Tarantool >= 2.10.0
+type Unwatch struct {
+	Key string
+}
+
+var _ Query = (*Unwatch)(nil)
+
+func (q *Unwatch) GetCommandID() uint {
+	return UnwatchCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Unwatch) MarshalMsg(b []byte) (o []byte, err error) {
+	return marshalEventKey(q.Key, b), nil
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Unwatch) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	q.Key, buf, err = unmarshalEventKey(data)
+	return
+}
+
+// Event is sent by the server to notify about the value of the watched key.
+// Data is nil if the key has no value.
+type Event struct {
+	Key  string
+	Data interface{}
+}
+
+var _ Query = (*Event)(nil)
+
+func (q *Event) GetCommandID() uint {
+	return EventCommand
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (q *Event) MarshalMsg(b []byte) (o []byte, err error) {
+	if q.Data == nil {
+		return marshalEventKey(q.Key, b), nil
+	}
+
+	o = msgp.AppendMapHeader(b, 2)
+	o = msgp.AppendUint(o, KeyEventKey)
+	o = msgp.AppendString(o, q.Key)
+	o = msgp.AppendUint(o, KeyEventData)
+	return msgp.AppendIntf(o, q.Data)
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (q *Event) UnmarshalMsg(data []byte) (buf []byte, err error) {
+	var i uint32
+	var k uint
+
+	q.Key = ""
+	q.Data = nil
+
+	buf = data
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		switch k {
+		case KeyEventKey:
+			q.Key, buf, err = msgp.ReadStringBytes(buf)
+		case KeyEventData:
+			q.Data, buf, err = readIntfBytes(buf)
+		default:
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func marshalEventKey(key string, o []byte) []byte {
+	o = msgp.AppendMapHeader(o, 1)
+	o = msgp.AppendUint(o, KeyEventKey)
+	return msgp.AppendString(o, key)
+}
+
+func unmarshalEventKey(data []byte) (key string, buf []byte, err error) {
+	var i uint32
+	var k uint
+
+	buf = data
+	if i, buf, err = msgp.ReadMapHeaderBytes(buf); err != nil {
+		return
+	}
+
+	for ; i > 0; i-- {
+		if k, buf, err = msgp.ReadUintBytes(buf); err != nil {
+			return
+		}
+
+		if k == KeyEventKey {
+			key, buf, err = msgp.ReadStringBytes(buf)
+		} else {
+			buf, err = msgp.Skip(buf)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,163 @@
+package tarantool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+type watchEvent struct {
+	key   string
+	value interface{}
+}
+
+func newWatchListener(t *testing.T) *iprotoListener {
+	return newIprotoListenerWithOptions(t, func(context.Context, Query) *Result {
+		return &Result{}
+	}, &IprotoServerOptions{
+		ProtocolInfo: &ProtocolInfo{
+			Features: []ProtocolFeature{FeatureWatchers},
+		},
+	})
+}
+
+func (l *iprotoListener) Broadcast(key string, value interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	for _, s := range l.servers {
+		s.Broadcast(key, value)
+	}
+}
+
+func recvWatchEvent(t *testing.T, events chan watchEvent) watchEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return watchEvent{}
+}
+
+func TestEventPackUnpack(t *testing.T) {
+	require := require.New(t)
+
+	for _, q := range []Query{
+		&Watch{Key: "box.status"},
+		&Unwatch{Key: "box.status"},
+		&Event{Key: "box.status"},
+		&Event{Key: "box.status", Data: map[string]interface{}{"ro": false}},
+	} {
+		buf, err := q.(msgp.Marshaler).MarshalMsg(nil)
+		require.NoError(err)
+
+		q2 := NewQuery(q.GetCommandID())
+		rest, err := q2.(msgp.Unmarshaler).UnmarshalMsg(buf)
+		require.NoError(err)
+		require.Empty(rest)
+		require.Equal(q, q2)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newWatchListener(t)
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	events := make(chan watchEvent, 16)
+	w, err := conn.NewWatcher("box.status", func(key string, value interface{}) {
+		events <- watchEvent{key, value}
+	})
+	require.NoError(err)
+	assert.Equal("box.status", w.Key())
+
+	// the key has no value yet
+	assert.Equal(watchEvent{"box.status", nil}, recvWatchEvent(t, events))
+
+	l.Broadcast("box.status", "running")
+	assert.Equal(watchEvent{"box.status", "running"}, recvWatchEvent(t, events))
+
+	l.Broadcast("box.status", "orphan")
+	assert.Equal(watchEvent{"box.status", "orphan"}, recvWatchEvent(t, events))
+
+	// the second watcher receives the current value
+	events2 := make(chan watchEvent, 16)
+	w2, err := conn.NewWatcher("box.status", func(key string, value interface{}) {
+		events2 <- watchEvent{key, value}
+	})
+	require.NoError(err)
+	assert.Equal(watchEvent{"box.status", "orphan"}, recvWatchEvent(t, events2))
+
+	w.Unregister()
+	l.Broadcast("box.status", "running")
+	assert.Equal(watchEvent{"box.status", "running"}, recvWatchEvent(t, events2))
+	w2.Unregister()
+
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %v", ev)
+	default:
+	}
+}
+
+func TestWatcherNotSupported(t *testing.T) {
+	l := newIprotoListener(t, func(context.Context, Query) *Result {
+		return &Result{}
+	})
+	defer l.Close()
+
+	conn, err := Connect(l.Addr(), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.NewWatcher("box.status", func(string, interface{}) {})
+	require.Equal(t, ErrWatchersNotSupported, err)
+}
+
+func TestConnectorWatcherReconnect(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l := newWatchListener(t)
+	defer l.Close()
+
+	c := New(l.Addr(), &Options{
+		Reconnect:      true,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	defer c.Close()
+
+	conn, err := c.Connect()
+	require.NoError(err)
+
+	events := make(chan watchEvent, 16)
+	w, err := c.NewWatcher("box.id", func(key string, value interface{}) {
+		events <- watchEvent{key, value}
+	})
+	require.NoError(err)
+	defer w.Unregister()
+	assert.Equal(watchEvent{"box.id", nil}, recvWatchEvent(t, events))
+
+	l.Drop()
+	<-conn.closed
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = c.ConnectContext(ctx)
+	require.NoError(err)
+
+	// the watcher is registered on the new connection
+	assert.Equal(watchEvent{"box.id", nil}, recvWatchEvent(t, events))
+	l.Broadcast("box.id", int64(1))
+	assert.Equal(watchEvent{"box.id", int64(1)}, recvWatchEvent(t, events))
+}
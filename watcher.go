@@ -0,0 +1,200 @@
+package tarantool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WatchCallback is called with the current value of the watched key
+// right after registration and then every time the value is changed.
+// Calls for the same key are never made concurrently.
+type WatchCallback func(key string, value interface{})
+
+// Watcher is a subscription to the box.broadcast key created by NewWatcher.
+type Watcher struct {
+	key          string
+	fn           WatchCallback
+	unregistered int32
+	unregister   func(w *Watcher)
+}
+
+// Key returns the watched key.
+func (w *Watcher) Key() string {
+	return w.key
+}
+
+// Unregister cancels the subscription. The callback is not called after Unregister has returned,
+// unless the call is already in progress.
+func (w *Watcher) Unregister() {
+	if atomic.CompareAndSwapInt32(&w.unregistered, 0, 1) {
+		w.unregister(w)
+	}
+}
+
+func (w *Watcher) notify(key string, value interface{}) {
+	if atomic.LoadInt32(&w.unregistered) == 0 {
+		w.fn(key, value)
+	}
+}
+
+// watchState holds the watchers of the key registered on the connection.
+type watchState struct {
+	sync.Mutex // serializes callbacks
+	watchers   []*Watcher
+	event      *Event // the last received value
+}
+
+// NewWatcher subscribes to the box.broadcast key, e.g. "box.status" or "box.id".
+// The callback receives the current value of the key and its subsequent changes.
+// The subscription is lost with the connection, see Connector.NewWatcher to keep it across reconnects.
+// It is available since Tarantool >= 2.10.0
+func (conn *Connection) NewWatcher(key string, fn WatchCallback) (*Watcher, error) {
+	if !conn.protocolInfo.Has(FeatureWatchers) {
+		return nil, ErrWatchersNotSupported
+	}
+
+	w := &Watcher{key: key, fn: fn, unregister: conn.unregisterWatcher}
+
+	conn.watchLock.Lock()
+	st := conn.watches[key]
+	register := st == nil
+	if register {
+		st = &watchState{}
+		conn.watches[key] = st
+	}
+	st.watchers = append(st.watchers, w)
+	event := st.event
+	conn.watchLock.Unlock()
+
+	if register {
+		if err := conn.send(context.Background(), &Watch{Key: key}); err != nil {
+			w.Unregister()
+			return nil, err
+		}
+	} else if event != nil {
+		// the server notifies about the key once per connection, so the known value is passed on
+		go func() {
+			st.Lock()
+			defer st.Unlock()
+			w.notify(event.Key, event.Data)
+		}()
+	}
+	return w, nil
+}
+
+func (conn *Connection) unregisterWatcher(w *Watcher) {
+	conn.watchLock.Lock()
+	st := conn.watches[w.key]
+	if st == nil {
+		conn.watchLock.Unlock()
+		return
+	}
+	for i, sw := range st.watchers {
+		if sw == w {
+			st.watchers = append(st.watchers[:i], st.watchers[i+1:]...)
+			break
+		}
+	}
+	unwatch := len(st.watchers) == 0
+	if unwatch {
+		delete(conn.watches, w.key)
+	}
+	conn.watchLock.Unlock()
+
+	if unwatch {
+		conn.send(context.Background(), &Unwatch{Key: w.key})
+	}
+}
+
+// handleEvent passes the value to the watchers of the key and acknowledges it,
+// the server doesn't send the next Event for the key until then.
+func (conn *Connection) handleEvent(event *Event) {
+	conn.watchLock.Lock()
+	st := conn.watches[event.Key]
+	if st == nil {
+		// the key has been unwatched in the meantime
+		conn.watchLock.Unlock()
+		return
+	}
+	st.event = event
+	watchers := append([]*Watcher(nil), st.watchers...)
+	conn.watchLock.Unlock()
+
+	go func() {
+		st.Lock()
+		for _, w := range watchers {
+			w.notify(event.Key, event.Data)
+		}
+		st.Unlock()
+
+		conn.watchLock.Lock()
+		ack := conn.watches[event.Key] == st
+		conn.watchLock.Unlock()
+		if ack {
+			conn.send(context.Background(), &Watch{Key: event.Key})
+		}
+	}()
+}
+
+// send writes the query which has no response.
+func (conn *Connection) send(ctx context.Context, q Query) error {
+	pp := packetPool.GetWithID(conn.nextID())
+	if err := pp.packMsg(q, conn.getPackData()); err != nil {
+		conn.releasePacket(pp)
+		return NewQueryError(ErrInvalidMsgpack, err.Error())
+	}
+
+	select {
+	case conn.writeChan <- &request{packet: pp}:
+		return nil
+	case <-ctx.Done():
+		conn.releasePacket(pp)
+		return NewContextError(ctx, conn, "Send error")
+	case <-conn.exit:
+		conn.releasePacket(pp)
+		return ConnectionClosedError(conn)
+	}
+}
+
+// NewWatcher subscribes to the box.broadcast key on the current connection
+// and registers the subscription again on every new connection established by Connector,
+// so the callback receives the current value of the key after reconnect.
+func (c *Connector) NewWatcher(key string, fn WatchCallback) (*Watcher, error) {
+	w := &Watcher{key: key, fn: fn, unregister: c.unregisterWatcher}
+
+	c.Lock()
+	defer c.Unlock()
+
+	var cw *Watcher
+	if c.conn != nil && !c.conn.IsClosed() {
+		var err error
+		if cw, err = c.conn.NewWatcher(key, w.notify); err != nil {
+			return nil, err
+		}
+	}
+	if c.watchers == nil {
+		c.watchers = make(map[*Watcher]*Watcher)
+	}
+	c.watchers[w] = cw
+	return w, nil
+}
+
+func (c *Connector) unregisterWatcher(w *Watcher) {
+	c.Lock()
+	cw := c.watchers[w]
+	delete(c.watchers, w)
+	c.Unlock()
+
+	if cw != nil {
+		cw.Unregister()
+	}
+}
+
+// rewatch registers watchers on the new connection, it must be called with the lock held.
+func (c *Connector) rewatch(conn *Connection) {
+	for w := range c.watchers {
+		cw, _ := conn.NewWatcher(w.key, w.notify)
+		c.watchers[w] = cw
+	}
+}